@@ -0,0 +1,116 @@
+// Package adaptive provides a concurrency limiter that adjusts its limit
+// automatically according to the latency and errors of the process.
+package adaptive
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/semaphore"
+)
+
+// Sample is a measurement of a single process.
+type Sample struct {
+	// RTT is the time taken by the process.
+	RTT time.Duration
+
+	// InFlight is the number of processes running when the process started.
+	InFlight int
+
+	// Dropped is true when the process failed.
+	Dropped bool
+}
+
+// Algorithm calculates the concurrency limit from samples.
+type Algorithm interface {
+	// InitialLimit returns the limit used before any sample is taken.
+	InitialLimit() int
+
+	// Update receives a sample and returns the new limit.
+	// Update is never called concurrently by the Limiter.
+	Update(s Sample) int
+}
+
+// Limiter is a guard.Guard with an adaptive concurrency limit.
+type Limiter interface {
+	guard.Guard
+
+	// Limit returns the current limit of concurrent processes.
+	Limit() int
+
+	// InUse returns the number of processes currently running.
+	InUse() int
+}
+
+// New creates a new Limiter that adjusts the limit by the algorithm.
+//
+// The processes exceeding the limit wait until the others finish
+// or the context is done.
+// The process that returns context.Canceled is not sampled,
+// and the process that returns other errors is sampled as dropped.
+func New(algorithm Algorithm, options ...LimiterOption) Limiter {
+	l := &limiter{
+		algorithm: algorithm,
+		sem:       semaphore.NewResizable(algorithm.InitialLimit()),
+		clock:     guard.SystemClock,
+	}
+	for _, o := range options {
+		o(l)
+	}
+	return l
+}
+
+// LimiterOption is the optional parameter for New.
+type LimiterOption func(*limiter)
+
+// WithClock set the clock used to measure the RTT.
+// The default is guard.SystemClock.
+func WithClock(c guard.Clock) LimiterOption {
+	return LimiterOption(func(l *limiter) {
+		l.clock = c
+	})
+}
+
+type limiter struct {
+	algorithm Algorithm
+	sem       semaphore.ResizableSemaphore
+	clock     guard.Clock
+	mu        sync.Mutex
+}
+
+func (l *limiter) Run(ctx context.Context, f func(context.Context) error) error {
+	return l.sem.Run(ctx, func(ctx context.Context) error {
+		inFlight := l.sem.InUse()
+		start := l.clock.Now()
+		err := f(ctx)
+		rtt := l.clock.Now().Sub(start)
+
+		if err == context.Canceled {
+			// this is normal, so do nothing.
+			return err
+		}
+
+		l.update(Sample{
+			RTT:      rtt,
+			InFlight: inFlight,
+			Dropped:  err != nil,
+		})
+		return err
+	})
+}
+
+func (l *limiter) update(s Sample) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sem.SetLimit(l.algorithm.Update(s))
+}
+
+func (l *limiter) Limit() int {
+	return l.sem.Limit()
+}
+
+func (l *limiter) InUse() int {
+	return l.sem.InUse()
+}
@@ -0,0 +1,165 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard/guardtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAlgorithm struct {
+	Samples []Sample
+	Limit   int
+}
+
+func (a *testAlgorithm) InitialLimit() int {
+	return a.Limit
+}
+
+func (a *testAlgorithm) Update(s Sample) int {
+	a.Samples = append(a.Samples, s)
+	return a.Limit
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(&testAlgorithm{Limit: 1})
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("samples should be passed to the algorithm", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := &testAlgorithm{Limit: 1}
+		clock := guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+		g := New(a, WithClock(clock))
+
+		g.Run(context.Background(), func(_ context.Context) error {
+			clock.Add(time.Second)
+			return nil
+		})
+		g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+		g.Run(context.Background(), func(_ context.Context) error {
+			return context.Canceled
+		})
+
+		if assert.Len(a.Samples, 2) {
+			assert.False(a.Samples[0].Dropped)
+			assert.Equal(time.Second, a.Samples[0].RTT)
+			assert.Equal(1, a.Samples[0].InFlight)
+			assert.True(a.Samples[1].Dropped)
+		}
+	})
+
+	t.Run("limit should follow the algorithm", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := &testAlgorithm{Limit: 1}
+		g := New(a)
+		assert.Equal(1, g.Limit())
+
+		a.Limit = 5
+		g.Run(context.Background(), func(_ context.Context) error {
+			assert.Equal(1, g.InUse())
+			return nil
+		})
+
+		assert.Equal(5, g.Limit())
+		assert.Equal(0, g.InUse())
+	})
+}
+
+func TestAIMD(t *testing.T) {
+	a := NewAIMD(WithInitialLimit(10), WithMinLimit(5), WithMaxLimit(12), WithTimeout(time.Second))
+
+	for _, tc := range []struct {
+		name   string
+		sample Sample
+		expect int
+	}{
+		{"success should increase the limit", Sample{RTT: time.Millisecond, InFlight: 10}, 11},
+		{"app limited success should keep the limit", Sample{RTT: time.Millisecond, InFlight: 1}, 11},
+		{"limit should not exceed the max", Sample{RTT: time.Millisecond, InFlight: 11}, 12},
+		{"limit should not exceed the max", Sample{RTT: time.Millisecond, InFlight: 12}, 12},
+		{"drop should decrease the limit", Sample{RTT: time.Millisecond, Dropped: true}, 10},
+		{"timeout should decrease the limit", Sample{RTT: 2 * time.Second, InFlight: 10}, 9},
+		{"drop should decrease the limit", Sample{Dropped: true}, 8},
+		{"drop should decrease the limit", Sample{Dropped: true}, 7},
+		{"drop should decrease the limit", Sample{Dropped: true}, 6},
+		{"limit should not fall below the min", Sample{Dropped: true}, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, a.Update(tc.sample))
+		})
+	}
+}
+
+func TestVegas(t *testing.T) {
+	a := NewVegas(WithInitialLimit(10))
+
+	for _, tc := range []struct {
+		name   string
+		sample Sample
+		expect int
+	}{
+		{"no queue should increase the limit by beta", Sample{RTT: 10 * time.Millisecond, InFlight: 10}, 16},
+		{"small queue should increase the limit by 1", Sample{RTT: 11 * time.Millisecond, InFlight: 10}, 17},
+		{"app limited sample should keep the limit", Sample{RTT: 10 * time.Millisecond, InFlight: 1}, 17},
+		{"large queue should decrease the limit", Sample{RTT: 20 * time.Millisecond, InFlight: 17}, 16},
+		{"drop should decrease the limit", Sample{RTT: 10 * time.Millisecond, Dropped: true}, 15},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, a.Update(tc.sample))
+		})
+	}
+}
+
+func TestGradient2(t *testing.T) {
+	t.Run("limit should grow with stable latency", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := NewGradient2(WithInitialLimit(10), WithMaxLimit(100))
+
+		limit := 0
+		for i := 0; i < 200; i++ {
+			limit = a.Update(Sample{RTT: 10 * time.Millisecond, InFlight: limit})
+		}
+		assert.Equal(100, limit)
+	})
+
+	t.Run("limit should shrink with increasing latency", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := NewGradient2(WithInitialLimit(50), WithLongWindow(100))
+		for i := 0; i < 100; i++ {
+			a.Update(Sample{RTT: 10 * time.Millisecond, InFlight: 50})
+		}
+		before := a.Update(Sample{RTT: 10 * time.Millisecond, InFlight: 50})
+
+		limit := before
+		for i := 0; i < 10; i++ {
+			limit = a.Update(Sample{RTT: 100 * time.Millisecond, InFlight: limit})
+		}
+		assert.True(limit < before, "%d < %d", limit, before)
+	})
+
+	t.Run("drop should shrink the limit", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := NewGradient2(WithInitialLimit(50), WithQueueSize(0))
+
+		assert.Equal(45, a.Update(Sample{RTT: 10 * time.Millisecond, Dropped: true}))
+	})
+}
@@ -0,0 +1,295 @@
+package adaptive
+
+import (
+	"math"
+	"time"
+)
+
+type params struct {
+	initialLimit float64
+	minLimit     float64
+	maxLimit     float64
+
+	// AIMD
+	backoffRatio float64
+	timeout      time.Duration
+
+	// Gradient2
+	tolerance  float64
+	smoothing  float64
+	longWindow float64
+	queueSize  float64
+}
+
+// Option is the optional parameter for the algorithms.
+type Option func(*params)
+
+// WithInitialLimit set the initial limit of the algorithm.
+func WithInitialLimit(n int) Option {
+	return Option(func(p *params) {
+		p.initialLimit = float64(n)
+	})
+}
+
+// WithMinLimit set the minimum limit of the algorithm.
+func WithMinLimit(n int) Option {
+	return Option(func(p *params) {
+		p.minLimit = float64(n)
+	})
+}
+
+// WithMaxLimit set the maximum limit of the algorithm.
+func WithMaxLimit(n int) Option {
+	return Option(func(p *params) {
+		p.maxLimit = float64(n)
+	})
+}
+
+// WithBackoffRatio set the ratio to decrease the limit on a drop.
+// This is used by AIMD.
+func WithBackoffRatio(f float64) Option {
+	return Option(func(p *params) {
+		p.backoffRatio = f
+	})
+}
+
+// WithTimeout set the RTT above which the sample is regarded as a drop.
+// This is used by AIMD.
+func WithTimeout(d time.Duration) Option {
+	return Option(func(p *params) {
+		p.timeout = d
+	})
+}
+
+// WithTolerance set the ratio of the long-term RTT to the short-term RTT
+// that is tolerated before the limit is decreased.
+// This is used by Gradient2.
+func WithTolerance(f float64) Option {
+	return Option(func(p *params) {
+		p.tolerance = f
+	})
+}
+
+// WithSmoothing set the factor in (0, 1] to smooth the change of the limit.
+// This is used by Gradient2.
+func WithSmoothing(f float64) Option {
+	return Option(func(p *params) {
+		p.smoothing = f
+	})
+}
+
+// WithLongWindow set the number of samples averaged as the long-term RTT.
+// This is used by Gradient2.
+func WithLongWindow(n int) Option {
+	return Option(func(p *params) {
+		p.longWindow = float64(n)
+	})
+}
+
+// WithQueueSize set the number of processes allowed to queue over the estimated limit.
+// This is used by Gradient2.
+func WithQueueSize(n int) Option {
+	return Option(func(p *params) {
+		p.queueSize = float64(n)
+	})
+}
+
+func newParams(defaults params, options []Option) params {
+	p := defaults
+	for _, o := range options {
+		o(&p)
+	}
+	return p
+}
+
+func (p params) clamp(limit float64) float64 {
+	return math.Max(p.minLimit, math.Min(p.maxLimit, limit))
+}
+
+// appLimited reports whether the process did not use the limit enough
+// to judge that the limit can be increased.
+func appLimited(limit float64, s Sample) bool {
+	return float64(s.InFlight)*2 < limit
+}
+
+// NewAIMD creates an Algorithm with additive-increase/multiplicative-decrease.
+//
+// The limit is increased by 1 on a success and multiplied by BackoffRatio on a drop.
+// A sample whose RTT exceeds Timeout is also regarded as a drop.
+//
+// The default parameters.
+//
+//  InitialLimit: 20
+//  MinLimit:     1
+//  MaxLimit:     200
+//  BackoffRatio: 0.9
+//  Timeout:      5 (s)
+func NewAIMD(options ...Option) Algorithm {
+	p := newParams(params{
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     200,
+		backoffRatio: 0.9,
+		timeout:      5 * time.Second,
+	}, options)
+	return &aimd{p, p.clamp(p.initialLimit)}
+}
+
+type aimd struct {
+	params
+	limit float64
+}
+
+func (a *aimd) InitialLimit() int {
+	return int(a.limit)
+}
+
+func (a *aimd) Update(s Sample) int {
+	switch {
+	case s.Dropped || s.RTT > a.timeout:
+		a.limit = math.Floor(a.limit * a.backoffRatio)
+	case !appLimited(a.limit, s):
+		a.limit++
+	}
+	a.limit = a.clamp(a.limit)
+	return int(a.limit)
+}
+
+// NewVegas creates an Algorithm based on TCP Vegas.
+//
+// The minimum RTT observed is regarded as the RTT without load,
+// and the number of queued processes is estimated by
+//
+//  QueueSize = ceil(Limit * (1 - MinRTT / RTT))
+//
+// Then the limit is changed by the following rule, where L = max(1, floor(log10(Limit))).
+//
+//  Dropped:               Limit - L
+//  QueueSize <= L:        Limit + 6L
+//  QueueSize <  3L:       Limit + L
+//  QueueSize >  6L:       Limit - L
+//  otherwise:             Limit
+//
+// The default parameters.
+//
+//  InitialLimit: 20
+//  MinLimit:     1
+//  MaxLimit:     1000
+func NewVegas(options ...Option) Algorithm {
+	p := newParams(params{
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     1000,
+	}, options)
+	return &vegas{params: p, limit: p.clamp(p.initialLimit)}
+}
+
+type vegas struct {
+	params
+	limit  float64
+	minRTT time.Duration
+}
+
+func (v *vegas) InitialLimit() int {
+	return int(v.limit)
+}
+
+func (v *vegas) Update(s Sample) int {
+	if s.RTT <= 0 {
+		return int(v.limit)
+	}
+	if v.minRTT == 0 || s.RTT < v.minRTT {
+		v.minRTT = s.RTT
+	}
+
+	l := math.Max(1, math.Floor(math.Log10(v.limit)))
+	alpha := 3 * l
+	beta := 6 * l
+	queueSize := math.Ceil(v.limit * (1 - float64(v.minRTT)/float64(s.RTT)))
+
+	switch {
+	case s.Dropped:
+		v.limit -= l
+	case appLimited(v.limit, s):
+	case queueSize <= l:
+		v.limit += beta
+	case queueSize < alpha:
+		v.limit += l
+	case queueSize > beta:
+		v.limit -= l
+	}
+	v.limit = v.clamp(v.limit)
+	return int(v.limit)
+}
+
+// NewGradient2 creates an Algorithm based on Gradient2 of Netflix concurrency-limits.
+//
+// The limit is calculated from the gradient of the long-term RTT
+// (exponential moving average over LongWindow samples) to the RTT of the sample.
+//
+//  Gradient = max(0.5, min(1, Tolerance * LongRTT / RTT))
+//  NewLimit = Limit * Gradient + QueueSize
+//  Limit    = Limit * (1 - Smoothing) + NewLimit * Smoothing
+//
+// A dropped sample is regarded as the gradient 0.5.
+//
+// The default parameters.
+//
+//  InitialLimit: 20
+//  MinLimit:     1
+//  MaxLimit:     200
+//  Tolerance:    1.5
+//  Smoothing:    0.2
+//  LongWindow:   600
+//  QueueSize:    4
+func NewGradient2(options ...Option) Algorithm {
+	p := newParams(params{
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     200,
+		tolerance:    1.5,
+		smoothing:    0.2,
+		longWindow:   600,
+		queueSize:    4,
+	}, options)
+	return &gradient2{params: p, limit: p.clamp(p.initialLimit)}
+}
+
+type gradient2 struct {
+	params
+	limit   float64
+	longRTT float64
+}
+
+func (g *gradient2) InitialLimit() int {
+	return int(g.limit)
+}
+
+func (g *gradient2) Update(s Sample) int {
+	rtt := float64(s.RTT)
+	if rtt <= 0 {
+		return int(g.limit)
+	}
+
+	if g.longRTT == 0 {
+		g.longRTT = rtt
+	} else {
+		g.longRTT = g.longRTT*(1-1/g.longWindow) + rtt/g.longWindow
+	}
+	// recover quickly from the long-term RTT that is stuck on high values.
+	if g.longRTT/rtt > 2 {
+		g.longRTT *= 0.95
+	}
+
+	if !s.Dropped && appLimited(g.limit, s) {
+		return int(g.limit)
+	}
+
+	gradient := 0.5
+	if !s.Dropped {
+		gradient = math.Max(0.5, math.Min(1, g.tolerance*g.longRTT/rtt))
+	}
+	newLimit := g.limit*gradient + g.queueSize
+	g.limit = g.clamp(g.limit*(1-g.smoothing) + newLimit*g.smoothing)
+	return int(g.limit)
+}
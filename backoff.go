@@ -2,7 +2,6 @@ package guard
 
 import (
 	"math"
-	"math/rand"
 	"sync/atomic"
 	"time"
 )
@@ -23,11 +22,15 @@ func NewConstantBackoff(d time.Duration) Backoff {
 }
 
 type constantBackoff struct {
-	Interval time.Duration
+	interval time.Duration
 }
 
 func (c *constantBackoff) NextInterval() time.Duration {
-	return c.Interval
+	return c.interval
+}
+
+func (c *constantBackoff) Interval(attempt int) (time.Duration, bool) {
+	return c.interval, true
 }
 
 func (c *constantBackoff) Reset() Backoff {
@@ -46,6 +49,10 @@ func (n noBackoff) NextInterval() time.Duration {
 	return 0
 }
 
+func (n noBackoff) Interval(attempt int) (time.Duration, bool) {
+	return 0, true
+}
+
 func (n noBackoff) Reset() Backoff {
 	return n
 }
@@ -64,7 +71,7 @@ func (n noBackoff) Reset() Backoff {
 //  MaxInterval:         1 (min)
 //  Multiplier:          2
 //  RandomizationFactor: 0.2
-//  Randomizer:          rand.New(rand.NewSource(time.Now().Unix()))
+//  Randomizer:          NewRandomizer()
 //
 // Example intervals.
 //
@@ -87,10 +94,14 @@ func (n noBackoff) Reset() Backoff {
 // Note: MaxInterval effects only the base interval.
 // The actual interval may exceed MaxInterval depending on RandomizationFactor.
 func NewExponentialBackoff(options ...ExponentialBackoffOption) Backoff {
+	return newExponentialBackoff(2, options)
+}
+
+func newExponentialBackoff(multiplier float64, options []ExponentialBackoffOption) *exponentialBackoff {
 	e := &exponentialBackoff{
 		initialInterval:     float64(200 * time.Millisecond),
 		maxInterval:         float64(time.Minute),
-		multiplier:          2,
+		multiplier:          multiplier,
 		randomizationFactor: 0.2,
 	}
 
@@ -99,7 +110,7 @@ func NewExponentialBackoff(options ...ExponentialBackoffOption) Backoff {
 	}
 
 	if e.randomizer == nil {
-		e.randomizer = rand.New(rand.NewSource(time.Now().Unix()))
+		e.randomizer = NewRandomizer()
 	}
 	e.baseInterval = math.Float64bits(e.initialInterval)
 
@@ -140,12 +151,126 @@ func (e *exponentialBackoff) BaseInterval() float64 {
 	}
 }
 
+// Interval returns the interval before the attempt-th retry without changing the state.
+func (e *exponentialBackoff) Interval(attempt int) (time.Duration, bool) {
+	rnd := (1 - e.randomizationFactor) + (2 * e.randomizationFactor * e.randomizer.Float64())
+	return time.Duration(e.baseIntervalAt(attempt) * rnd), true
+}
+
+// baseIntervalAt calculates BaseInterval(N) without changing the state.
+func (e *exponentialBackoff) baseIntervalAt(n int) float64 {
+	if n < 1 {
+		n = 1
+	}
+	d := e.initialInterval * math.Pow(e.multiplier, float64(n-1))
+	if d > e.maxInterval || math.IsInf(d, 0) || math.IsNaN(d) {
+		return e.maxInterval
+	}
+	return d
+}
+
 func (e *exponentialBackoff) Reset() Backoff {
+	return e.reset()
+}
+
+func (e *exponentialBackoff) reset() *exponentialBackoff {
 	clone := *e
 	clone.baseInterval = math.Float64bits(clone.initialInterval)
 	return &clone
 }
 
+// FullJitterBackoff creates Backoff with an exponential backoff and "Full Jitter".
+//
+// The parameters are same as ExponentialBackoff except RandomizationFactor that is not used.
+//
+//  NextInterval(N) = BaseInterval(N) * [0, 1)
+func NewFullJitterBackoff(options ...ExponentialBackoffOption) Backoff {
+	return &fullJitterBackoff{newExponentialBackoff(2, options)}
+}
+
+type fullJitterBackoff struct {
+	*exponentialBackoff
+}
+
+func (f *fullJitterBackoff) NextInterval() time.Duration {
+	return time.Duration(f.BaseInterval() * f.randomizer.Float64())
+}
+
+func (f *fullJitterBackoff) Interval(attempt int) (time.Duration, bool) {
+	return time.Duration(f.baseIntervalAt(attempt) * f.randomizer.Float64()), true
+}
+
+func (f *fullJitterBackoff) Reset() Backoff {
+	return &fullJitterBackoff{f.reset()}
+}
+
+// EqualJitterBackoff creates Backoff with an exponential backoff and "Equal Jitter".
+//
+// The parameters are same as ExponentialBackoff except RandomizationFactor that is not used.
+//
+//  NextInterval(N) = BaseInterval(N) / 2 + BaseInterval(N) / 2 * [0, 1)
+func NewEqualJitterBackoff(options ...ExponentialBackoffOption) Backoff {
+	return &equalJitterBackoff{newExponentialBackoff(2, options)}
+}
+
+type equalJitterBackoff struct {
+	*exponentialBackoff
+}
+
+func (e *equalJitterBackoff) NextInterval() time.Duration {
+	half := e.BaseInterval() / 2
+	return time.Duration(half + half*e.randomizer.Float64())
+}
+
+func (e *equalJitterBackoff) Interval(attempt int) (time.Duration, bool) {
+	half := e.baseIntervalAt(attempt) / 2
+	return time.Duration(half + half*e.randomizer.Float64()), true
+}
+
+func (e *equalJitterBackoff) Reset() Backoff {
+	return &equalJitterBackoff{e.reset()}
+}
+
+// DecorrelatedJitterBackoff creates Backoff with "Decorrelated Jitter".
+//
+// The parameters are same as ExponentialBackoff except RandomizationFactor that is not used,
+// and the default Multiplier is 3.
+//
+//  NextInterval(N) = min(MaxInterval, [InitialInterval, NextInterval(N-1) * Multiplier))
+//  NextInterval(0) = InitialInterval
+func NewDecorrelatedJitterBackoff(options ...ExponentialBackoffOption) Backoff {
+	return &decorrelatedJitterBackoff{newExponentialBackoff(3, options)}
+}
+
+// decorrelatedJitterBackoff uses baseInterval of exponentialBackoff as the previous interval.
+// It does not embed exponentialBackoff because the interval depends on the previous one
+// and cannot be calculated from the attempt.
+type decorrelatedJitterBackoff struct {
+	e *exponentialBackoff
+}
+
+func (d *decorrelatedJitterBackoff) NextInterval() time.Duration {
+	e := d.e
+	rnd := e.randomizer.Float64()
+	for {
+		old := atomic.LoadUint64(&e.baseInterval)
+		prev := math.Float64frombits(old)
+		upper := prev * e.multiplier
+		new := e.initialInterval + (upper-e.initialInterval)*rnd
+
+		if new > e.maxInterval {
+			new = e.maxInterval
+		}
+		if atomic.CompareAndSwapUint64(&e.baseInterval, old, math.Float64bits(new)) {
+			return time.Duration(new)
+		}
+	}
+}
+
+func (d *decorrelatedJitterBackoff) Reset() Backoff {
+	return &decorrelatedJitterBackoff{d.e.reset()}
+}
+
 // ExponentialBackoffOption is the optional parameter for ExponentialBackoff.
 type ExponentialBackoffOption func(*exponentialBackoff)
 
@@ -183,3 +308,164 @@ func WithRandomizer(r Randomizer) ExponentialBackoffOption {
 		e.randomizer = r
 	})
 }
+
+// LinearBackoff creates Backoff with a linear backoff.
+//
+//  NextInterval(N) = min(InitialInterval + Increment * (N-1), MaxInterval)
+func NewLinearBackoff(initialInterval, increment, maxInterval time.Duration) Backoff {
+	return &linearBackoff{
+		initialInterval: initialInterval,
+		increment:       increment,
+		maxInterval:     maxInterval,
+	}
+}
+
+type linearBackoff struct {
+	initialInterval time.Duration
+	increment       time.Duration
+	maxInterval     time.Duration
+
+	count int64
+}
+
+func (l *linearBackoff) NextInterval() time.Duration {
+	d, _ := l.Interval(int(atomic.AddInt64(&l.count, 1)))
+	return d
+}
+
+func (l *linearBackoff) Interval(attempt int) (time.Duration, bool) {
+	n := int64(attempt - 1)
+	if l.increment > 0 && n > int64((l.maxInterval-l.initialInterval)/l.increment) {
+		return l.maxInterval, true
+	}
+	d := l.initialInterval + l.increment*time.Duration(n)
+	if d > l.maxInterval {
+		return l.maxInterval, true
+	}
+	return d, true
+}
+
+func (l *linearBackoff) Reset() Backoff {
+	return NewLinearBackoff(l.initialInterval, l.increment, l.maxInterval)
+}
+
+// FibonacciBackoff creates Backoff with a backoff that follows the Fibonacci sequence.
+//
+//  NextInterval(N) = min(Unit * Fib(N), MaxInterval)
+//  Fib(N)          = Fib(N-1) + Fib(N-2)
+//  Fib(1), Fib(2)  = 1
+func NewFibonacciBackoff(unit, maxInterval time.Duration) Backoff {
+	return &fibonacciBackoff{
+		unit:        unit,
+		maxInterval: maxInterval,
+	}
+}
+
+type fibonacciBackoff struct {
+	unit        time.Duration
+	maxInterval time.Duration
+
+	count int64
+}
+
+func (f *fibonacciBackoff) NextInterval() time.Duration {
+	d, _ := f.Interval(int(atomic.AddInt64(&f.count, 1)))
+	return d
+}
+
+func (f *fibonacciBackoff) Interval(attempt int) (time.Duration, bool) {
+	prev, curr := time.Duration(0), f.unit
+	for i := 1; i < attempt; i++ {
+		if curr >= f.maxInterval {
+			break
+		}
+		prev, curr = curr, prev+curr
+	}
+	if curr > f.maxInterval {
+		return f.maxInterval, true
+	}
+	return curr, true
+}
+
+func (f *fibonacciBackoff) Reset() Backoff {
+	return NewFibonacciBackoff(f.unit, f.maxInterval)
+}
+
+// ScheduleBackoff creates Backoff that returns the intervals in the schedule in order.
+// After the schedule is exhausted, the last interval is repeated by default.
+//
+//  NextInterval(N) = Schedule[N-1]
+func NewScheduleBackoff(schedule []time.Duration, options ...ScheduleBackoffOption) Backoff {
+	s := &scheduleBackoff{
+		schedule: append([]time.Duration(nil), schedule...),
+	}
+	if len(schedule) > 0 {
+		s.exhausted = func(n int) time.Duration {
+			return s.schedule[len(s.schedule)-1]
+		}
+	} else {
+		s.exhausted = func(n int) time.Duration {
+			return 0
+		}
+	}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+type scheduleBackoff struct {
+	schedule  []time.Duration
+	exhausted func(n int) time.Duration
+
+	count int64
+}
+
+func (s *scheduleBackoff) NextInterval() time.Duration {
+	d, _ := s.Interval(int(atomic.AddInt64(&s.count, 1)))
+	return d
+}
+
+func (s *scheduleBackoff) Interval(attempt int) (time.Duration, bool) {
+	n := attempt - 1
+	if n < 0 {
+		n = 0
+	}
+	if n < len(s.schedule) {
+		return s.schedule[n], true
+	}
+	d := s.exhausted(n)
+	return d, d != Stop
+}
+
+func (s *scheduleBackoff) Reset() Backoff {
+	clone := *s
+	clone.count = 0
+	return &clone
+}
+
+// ScheduleBackoffOption is the optional parameter for ScheduleBackoff.
+type ScheduleBackoffOption func(*scheduleBackoff)
+
+// WithRepeatSchedule makes ScheduleBackoff repeat the whole schedule after it is exhausted.
+func WithRepeatSchedule() ScheduleBackoffOption {
+	return ScheduleBackoffOption(func(s *scheduleBackoff) {
+		if len(s.schedule) == 0 {
+			return
+		}
+		s.exhausted = func(n int) time.Duration {
+			return s.schedule[n%len(s.schedule)]
+		}
+	})
+}
+
+// WithExhaustedInterval makes ScheduleBackoff return d after the schedule is exhausted.
+func WithExhaustedInterval(d time.Duration) ScheduleBackoffOption {
+	return ScheduleBackoffOption(func(s *scheduleBackoff) {
+		s.exhausted = func(n int) time.Duration {
+			return d
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package guard
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// AttemptBackoff is a stateless strategy of backoff interval.
+// Unlike Backoff, it calculates the interval from the attempt number,
+// so that a single AttemptBackoff can be shared across goroutines without Reset.
+//
+// The constant, no, exponential, full jitter, equal jitter, linear, Fibonacci
+// and schedule backoffs implement AttemptBackoff.
+type AttemptBackoff interface {
+	// Interval returns the interval before the attempt-th retry, starting at 1,
+	// and false if no more retries should be made.
+	Interval(attempt int) (time.Duration, bool)
+}
+
+// AttemptBackoffFunc is an adapter to use a function as AttemptBackoff.
+type AttemptBackoffFunc func(attempt int) (time.Duration, bool)
+
+// Interval implements AttemptBackoff.
+func (f AttemptBackoffFunc) Interval(attempt int) (time.Duration, bool) {
+	return f(attempt)
+}
+
+// FromAttemptBackoff converts a into Backoff that counts the attempts by itself.
+// The returned Backoff also implements AttemptBackoff and StoppableBackoff.
+func FromAttemptBackoff(a AttemptBackoff) Backoff {
+	if b, ok := a.(Backoff); ok {
+		return b
+	}
+	return &countingBackoff{backoff: a}
+}
+
+type countingBackoff struct {
+	backoff AttemptBackoff
+
+	count int64
+}
+
+func (c *countingBackoff) Next() (time.Duration, bool) {
+	return c.backoff.Interval(int(atomic.AddInt64(&c.count, 1)))
+}
+
+func (c *countingBackoff) NextInterval() time.Duration {
+	return nextInterval(c)
+}
+
+func (c *countingBackoff) Interval(attempt int) (time.Duration, bool) {
+	return c.backoff.Interval(attempt)
+}
+
+func (c *countingBackoff) Reset() Backoff {
+	return &countingBackoff{backoff: c.backoff}
+}
+
+// ToAttemptBackoff converts b into AttemptBackoff.
+// If b does not implement AttemptBackoff, Interval replays a clone of b
+// created by Reset until the attempt, so it costs O(attempt).
+func ToAttemptBackoff(b Backoff) AttemptBackoff {
+	if a, ok := b.(AttemptBackoff); ok {
+		return a
+	}
+	return replayBackoff{b}
+}
+
+type replayBackoff struct {
+	backoff Backoff
+}
+
+func (r replayBackoff) Interval(attempt int) (time.Duration, bool) {
+	b := r.backoff.Reset()
+	for i := 1; i < attempt; i++ {
+		if _, ok := Next(b); !ok {
+			return 0, false
+		}
+	}
+	return Next(b)
+}
@@ -0,0 +1,192 @@
+package guard
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stop is the interval returned by Backoff to indicate that no more retries should be made.
+const Stop time.Duration = -1
+
+// StoppableBackoff is a Backoff that can tell to give up.
+// NextInterval of StoppableBackoff returns Stop when Next returns false.
+type StoppableBackoff interface {
+	Backoff
+
+	// Next returns the next interval and true, or false
+	// if no more retries should be made.
+	Next() (time.Duration, bool)
+}
+
+// Next returns the next interval of b and whether to continue.
+// If b does not implement StoppableBackoff, it continues until
+// NextInterval returns Stop.
+func Next(b Backoff) (time.Duration, bool) {
+	if s, ok := b.(StoppableBackoff); ok {
+		return s.Next()
+	}
+	d := b.NextInterval()
+	return d, d != Stop
+}
+
+// Stoppable converts b into StoppableBackoff.
+// The returned StoppableBackoff stops when NextInterval of b returns Stop.
+func Stoppable(b Backoff) StoppableBackoff {
+	if s, ok := b.(StoppableBackoff); ok {
+		return s
+	}
+	return stoppableBackoff{b}
+}
+
+type stoppableBackoff struct {
+	backoff Backoff
+}
+
+func (s stoppableBackoff) Next() (time.Duration, bool) {
+	return Next(s.backoff)
+}
+
+func (s stoppableBackoff) NextInterval() time.Duration {
+	return s.backoff.NextInterval()
+}
+
+func (s stoppableBackoff) Reset() Backoff {
+	return stoppableBackoff{s.backoff.Reset()}
+}
+
+func nextInterval(b StoppableBackoff) time.Duration {
+	d, ok := b.Next()
+	if !ok {
+		return Stop
+	}
+	return d
+}
+
+// NewCappedBackoff creates Backoff that limits the intervals of b to max.
+// Unlike MaxInterval of ExponentialBackoff, the interval after randomization is capped.
+func NewCappedBackoff(b Backoff, max time.Duration) Backoff {
+	return &cappedBackoff{b, max}
+}
+
+type cappedBackoff struct {
+	backoff Backoff
+	max     time.Duration
+}
+
+func (c *cappedBackoff) Next() (time.Duration, bool) {
+	d, ok := Next(c.backoff)
+	if ok && d > c.max {
+		return c.max, true
+	}
+	return d, ok
+}
+
+func (c *cappedBackoff) NextInterval() time.Duration {
+	return nextInterval(c)
+}
+
+func (c *cappedBackoff) Reset() Backoff {
+	return &cappedBackoff{c.backoff.Reset(), c.max}
+}
+
+// NewFlooredBackoff creates Backoff that raises the intervals of b to at least min.
+func NewFlooredBackoff(b Backoff, min time.Duration) Backoff {
+	return &flooredBackoff{b, min}
+}
+
+type flooredBackoff struct {
+	backoff Backoff
+	min     time.Duration
+}
+
+func (f *flooredBackoff) Next() (time.Duration, bool) {
+	d, ok := Next(f.backoff)
+	if ok && d < f.min {
+		return f.min, true
+	}
+	return d, ok
+}
+
+func (f *flooredBackoff) NextInterval() time.Duration {
+	return nextInterval(f)
+}
+
+func (f *flooredBackoff) Reset() Backoff {
+	return &flooredBackoff{f.backoff.Reset(), f.min}
+}
+
+// NewMaxRetriesBackoff creates Backoff that stops after n intervals of b.
+func NewMaxRetriesBackoff(b Backoff, n int) Backoff {
+	return &maxRetriesBackoff{backoff: b, max: int64(n)}
+}
+
+type maxRetriesBackoff struct {
+	backoff Backoff
+	max     int64
+
+	count int64
+}
+
+func (m *maxRetriesBackoff) Next() (time.Duration, bool) {
+	if atomic.AddInt64(&m.count, 1) > m.max {
+		return 0, false
+	}
+	return Next(m.backoff)
+}
+
+func (m *maxRetriesBackoff) NextInterval() time.Duration {
+	return nextInterval(m)
+}
+
+func (m *maxRetriesBackoff) Reset() Backoff {
+	return &maxRetriesBackoff{backoff: m.backoff.Reset(), max: m.max}
+}
+
+// NewMaxElapsedTimeBackoff creates Backoff that stops when the time elapsed
+// since the creation or the last Reset plus the next interval exceeds max.
+func NewMaxElapsedTimeBackoff(b Backoff, max time.Duration, options ...MaxElapsedTimeBackoffOption) Backoff {
+	m := &maxElapsedTimeBackoff{
+		backoff: b,
+		max:     max,
+		clock:   SystemClock,
+	}
+	for _, o := range options {
+		o(m)
+	}
+	m.start = m.clock.Now()
+	return m
+}
+
+// MaxElapsedTimeBackoffOption is the optional parameter for NewMaxElapsedTimeBackoff.
+type MaxElapsedTimeBackoffOption func(*maxElapsedTimeBackoff)
+
+// WithClock set the clock used to measure the elapsed time.
+// The default is SystemClock.
+func WithClock(c Clock) MaxElapsedTimeBackoffOption {
+	return MaxElapsedTimeBackoffOption(func(m *maxElapsedTimeBackoff) {
+		m.clock = c
+	})
+}
+
+type maxElapsedTimeBackoff struct {
+	backoff Backoff
+	max     time.Duration
+	clock   Clock
+	start   time.Time
+}
+
+func (m *maxElapsedTimeBackoff) Next() (time.Duration, bool) {
+	d, ok := Next(m.backoff)
+	if !ok || m.clock.Now().Sub(m.start)+d > m.max {
+		return 0, false
+	}
+	return d, true
+}
+
+func (m *maxElapsedTimeBackoff) NextInterval() time.Duration {
+	return nextInterval(m)
+}
+
+func (m *maxElapsedTimeBackoff) Reset() Backoff {
+	return &maxElapsedTimeBackoff{m.backoff.Reset(), m.max, m.clock, m.clock.Now()}
+}
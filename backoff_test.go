@@ -0,0 +1,306 @@
+package guard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRandomizer returns the numbers in order.
+type testRandomizer struct {
+	Numbers []float64
+	idx     int
+}
+
+func (r *testRandomizer) Float64() float64 {
+	n := r.Numbers[r.idx%len(r.Numbers)]
+	r.idx++
+	return n
+}
+
+func TestJitterBackoff(t *testing.T) {
+	options := func(r Randomizer) []ExponentialBackoffOption {
+		return []ExponentialBackoffOption{
+			WithInitialInterval(100 * time.Millisecond),
+			WithMaxInterval(time.Second),
+			WithRandomizer(r),
+		}
+	}
+	ms := time.Millisecond
+
+	for _, tc := range []struct {
+		name    string
+		backoff func(r Randomizer) Backoff
+		numbers []float64
+		expect  []time.Duration
+	}{
+		{
+			"full jitter",
+			func(r Randomizer) Backoff { return NewFullJitterBackoff(options(r)...) },
+			[]float64{0, 0.5, 0.5, 0.99, 0.5, 0.5},
+			[]time.Duration{0, 100 * ms, 200 * ms, 792 * ms, 500 * ms, 500 * ms},
+		},
+		{
+			"equal jitter",
+			func(r Randomizer) Backoff { return NewEqualJitterBackoff(options(r)...) },
+			[]float64{0, 0.5, 0.5, 0, 0.5, 0.5},
+			[]time.Duration{50 * ms, 150 * ms, 300 * ms, 400 * ms, 750 * ms, 750 * ms},
+		},
+		{
+			"decorrelated jitter",
+			func(r Randomizer) Backoff { return NewDecorrelatedJitterBackoff(options(r)...) },
+			[]float64{0.5, 0.5, 0, 0.5, 0.9, 0.9},
+			[]time.Duration{200 * ms, 350 * ms, 100 * ms, 200 * ms, 550 * ms, time.Second},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b := tc.backoff(&testRandomizer{Numbers: tc.numbers})
+
+			intervals := make([]time.Duration, len(tc.expect))
+			for i := range intervals {
+				intervals[i] = b.NextInterval()
+			}
+			assert.Equal(tc.expect, intervals)
+
+			// Reset should restart from the initial state.
+			b = b.Reset()
+			assert.Equal(tc.expect[0], b.NextInterval())
+		})
+	}
+
+	t.Run("max elapsed time should be measured by the clock", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := &fixedClock{now: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)}
+		b := NewMaxElapsedTimeBackoff(NewConstantBackoff(100*ms), 250*ms, WithClock(clock))
+
+		assert.Equal(100*ms, b.NextInterval())
+		clock.now = clock.now.Add(200 * ms)
+		assert.Equal(Stop, b.NextInterval())
+	})
+}
+
+// fixedClock is a Clock whose Now returns now.
+type fixedClock struct {
+	Clock
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestDeterministicBackoff(t *testing.T) {
+	ms := time.Millisecond
+	schedule := []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+	for _, tc := range []struct {
+		name    string
+		backoff Backoff
+		expect  []time.Duration
+	}{
+		{
+			"linear",
+			NewLinearBackoff(100*ms, 50*ms, 300*ms),
+			[]time.Duration{100 * ms, 150 * ms, 200 * ms, 250 * ms, 300 * ms, 300 * ms},
+		},
+		{
+			"fibonacci",
+			NewFibonacciBackoff(100*ms, time.Second),
+			[]time.Duration{100 * ms, 100 * ms, 200 * ms, 300 * ms, 500 * ms, 800 * ms, time.Second, time.Second},
+		},
+		{
+			"schedule repeating the last interval",
+			NewScheduleBackoff(schedule),
+			[]time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 30 * time.Second},
+		},
+		{
+			"schedule repeating the whole schedule",
+			NewScheduleBackoff(schedule, WithRepeatSchedule()),
+			[]time.Duration{time.Second, 5 * time.Second, 30 * time.Second, time.Second, 5 * time.Second},
+		},
+		{
+			"schedule with the exhausted interval",
+			NewScheduleBackoff(schedule, WithExhaustedInterval(2*time.Minute)),
+			[]time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute, 2 * time.Minute},
+		},
+		{
+			"empty schedule",
+			NewScheduleBackoff(nil, WithRepeatSchedule()),
+			[]time.Duration{0, 0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b := tc.backoff
+
+			intervals := make([]time.Duration, len(tc.expect))
+			for i := range intervals {
+				intervals[i] = b.NextInterval()
+			}
+			assert.Equal(tc.expect, intervals)
+
+			// Reset should restart from the initial state.
+			b = b.Reset()
+			assert.Equal(tc.expect[0], b.NextInterval())
+		})
+	}
+}
+
+func TestBackoffDecorator(t *testing.T) {
+	ms := time.Millisecond
+	linear := func() Backoff { return NewLinearBackoff(100*ms, 100*ms, time.Second) }
+
+	for _, tc := range []struct {
+		name    string
+		backoff Backoff
+		expect  []time.Duration
+	}{
+		{
+			"capped",
+			NewCappedBackoff(linear(), 250*ms),
+			[]time.Duration{100 * ms, 200 * ms, 250 * ms, 250 * ms},
+		},
+		{
+			"floored",
+			NewFlooredBackoff(NewFibonacciBackoff(50*ms, time.Second), 100*ms),
+			[]time.Duration{100 * ms, 100 * ms, 100 * ms, 150 * ms, 250 * ms},
+		},
+		{
+			"max retries",
+			NewMaxRetriesBackoff(linear(), 2),
+			[]time.Duration{100 * ms, 200 * ms, Stop, Stop},
+		},
+		{
+			"max elapsed time",
+			NewMaxElapsedTimeBackoff(linear(), 250*ms),
+			[]time.Duration{100 * ms, 200 * ms, Stop},
+		},
+		{
+			"floored should keep stop",
+			NewFlooredBackoff(NewMaxRetriesBackoff(linear(), 1), 150*ms),
+			[]time.Duration{150 * ms, Stop},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b := tc.backoff
+
+			intervals := make([]time.Duration, len(tc.expect))
+			for i := range intervals {
+				intervals[i] = b.NextInterval()
+			}
+			assert.Equal(tc.expect, intervals)
+
+			// Reset should restart from the initial state.
+			b = b.Reset()
+			assert.Equal(tc.expect[0], b.NextInterval())
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	ms := time.Millisecond
+
+	for _, tc := range []struct {
+		name    string
+		backoff Backoff
+		expect  []bool
+	}{
+		{
+			"plain backoff should never stop",
+			NewConstantBackoff(ms),
+			[]bool{true, true, true},
+		},
+		{
+			"plain backoff returning stop should stop",
+			NewScheduleBackoff([]time.Duration{ms}, WithExhaustedInterval(Stop)),
+			[]bool{true, false, false},
+		},
+		{
+			"stoppable backoff should stop",
+			NewCappedBackoff(NewMaxRetriesBackoff(NewConstantBackoff(ms), 2), ms),
+			[]bool{true, true, false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b := Stoppable(tc.backoff)
+
+			oks := make([]bool, len(tc.expect))
+			for i := range oks {
+				_, oks[i] = b.Next()
+			}
+			assert.Equal(tc.expect, oks)
+
+			_, ok := Next(b.Reset())
+			assert.True(ok)
+		})
+	}
+}
+
+func TestAttemptBackoff(t *testing.T) {
+	ms := time.Millisecond
+
+	t.Run("interval should match the sequence of next interval", func(t *testing.T) {
+		for _, b := range []Backoff{
+			NewConstantBackoff(ms),
+			NewNoBackoff(),
+			NewExponentialBackoff(WithRandomizationFactor(0)),
+			NewFullJitterBackoff(WithRandomizer(&testRandomizer{Numbers: []float64{0.5}})),
+			NewEqualJitterBackoff(WithRandomizer(&testRandomizer{Numbers: []float64{0.5}})),
+			NewLinearBackoff(100*ms, 50*ms, 300*ms),
+			NewFibonacciBackoff(100*ms, time.Second),
+			NewScheduleBackoff([]time.Duration{time.Second, 5 * time.Second}, WithRepeatSchedule()),
+		} {
+			assert := assert.New(t)
+
+			a, ok := b.(AttemptBackoff)
+			if !assert.True(ok, "%T", b) {
+				continue
+			}
+			for i := 1; i <= 10; i++ {
+				d, ok := a.Interval(i)
+				assert.True(ok)
+				assert.Equal(b.NextInterval(), d, "%T attempt %d", b, i)
+			}
+		}
+	})
+
+	t.Run("attempt backoff should be converted into backoff", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := AttemptBackoffFunc(func(attempt int) (time.Duration, bool) {
+			return time.Duration(attempt) * ms, attempt <= 2
+		})
+		b := FromAttemptBackoff(a)
+
+		assert.Equal(ms, b.NextInterval())
+		assert.Equal(2*ms, b.NextInterval())
+		assert.Equal(Stop, b.NextInterval())
+
+		b = b.Reset()
+		assert.Equal(ms, b.NextInterval())
+	})
+
+	t.Run("backoff should be converted into attempt backoff", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := ToAttemptBackoff(NewMaxRetriesBackoff(NewLinearBackoff(ms, ms, time.Second), 2))
+
+		d, ok := a.Interval(2)
+		assert.Equal(2*ms, d)
+		assert.True(ok)
+		d, ok = a.Interval(1)
+		assert.Equal(ms, d)
+		assert.True(ok)
+		_, ok = a.Interval(3)
+		assert.False(ok)
+	})
+}
@@ -0,0 +1,139 @@
+// Package bulkhead provides a bulkhead that runs the process on an isolated worker pool.
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/morikuni/guard"
+)
+
+// ErrBulkheadFull is a error that is returned when the queue of the bulkhead is full.
+var ErrBulkheadFull = errors.New("bulkhead full")
+
+// ErrBulkheadClosed is a error that is returned when the bulkhead is already closed.
+var ErrBulkheadClosed = errors.New("bulkhead closed")
+
+// Bulkhead is a guard.Guard with an additional method.
+type Bulkhead interface {
+	guard.Guard
+
+	// Close stops the workers after the queued processes finish.
+	Close()
+}
+
+// New creates a new Bulkhead that runs the process on one of the workers,
+// with a queue that holds up to queueSize processes waiting for a worker.
+//
+// The caller waits for the process to finish, or returns the error of the
+// context as soon as the context is done. In the latter case, the process
+// keeps running on the worker with the cancelled context, so that a slow
+// dependency consumes only the workers of its bulkhead.
+func New(workers, queueSize int) Bulkhead {
+	b := &bulkhead{
+		slots: make(chan struct{}, workers+queueSize),
+		queue: make(chan task, workers+queueSize),
+	}
+	b.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go b.work()
+	}
+	return b
+}
+
+type task struct {
+	ctx    context.Context
+	f      func(context.Context) error
+	result chan<- outcome
+}
+
+// outcome is the result of the process.
+// panic holds the value of the panic in the worker, which is propagated to
+// the caller since the panic in the worker cannot be recovered by the caller.
+type outcome struct {
+	err   error
+	panic interface{}
+}
+
+type bulkhead struct {
+	slots  chan struct{} // processes running or waiting for a worker.
+	queue  chan task
+	wg     sync.WaitGroup
+	closed bool
+	mu     sync.RWMutex
+}
+
+func (b *bulkhead) Run(ctx context.Context, f func(context.Context) error) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	result := make(chan outcome, 1)
+	if err := b.enqueue(task{ctx, f, result}); err != nil {
+		return err
+	}
+
+	select {
+	case o := <-result:
+		if o.panic != nil {
+			panic(o.panic)
+		}
+		return o.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *bulkhead) enqueue(t task) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBulkheadClosed
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		b.queue <- t
+		return nil
+	default:
+		return ErrBulkheadFull
+	}
+}
+
+func (b *bulkhead) work() {
+	defer b.wg.Done()
+	for t := range b.queue {
+		b.run(t)
+	}
+}
+
+func (b *bulkhead) run(t task) {
+	var o outcome
+	defer func() {
+		if e := recover(); e != nil {
+			o.panic = e
+		}
+		t.result <- o
+		<-b.slots
+	}()
+
+	if err := t.ctx.Err(); err != nil {
+		// the caller already gave up.
+		o.err = err
+		return
+	}
+	o.err = t.f(t.ctx)
+}
+
+func (b *bulkhead) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
@@ -0,0 +1,112 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/panicguard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkhead(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(1, 1)
+		defer g.Close()
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("process should be rejected when the queue is full", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(2, 1)
+		defer g.Close()
+
+		release := make(chan struct{})
+		var count int32
+		for i := 0; i < 3; i++ {
+			go g.Run(context.Background(), func(_ context.Context) error {
+				atomic.AddInt32(&count, 1)
+				<-release
+				return nil
+			})
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return nil
+		})
+		assert.Equal(ErrBulkheadFull, err)
+		assert.Equal(int32(2), atomic.LoadInt32(&count))
+
+		close(release)
+	})
+
+	t.Run("caller should return when the context is done", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(1, 0)
+		defer g.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		started := make(chan struct{})
+		finished := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- g.Run(ctx, func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				time.Sleep(10 * time.Millisecond)
+				close(finished)
+				return nil
+			})
+		}()
+		<-started
+		cancel()
+
+		assert.Equal(context.Canceled, <-done)
+		<-finished
+	})
+
+	t.Run("process should be rejected after close", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(1, 1)
+		g.Close()
+		g.Close()
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return nil
+		})
+		assert.Equal(ErrBulkheadClosed, err)
+	})
+
+	t.Run("panic in the worker should be propagated to the caller", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b := New(1, 0)
+		defer b.Close()
+		g := guard.Compose(panicguard.New(), b)
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			panic("boom")
+		})
+		assert.EqualError(err, "panic occured: boom")
+
+		// the slot should be released.
+		err = g.Run(context.Background(), func(_ context.Context) error {
+			return nil
+		})
+		assert.NoError(err)
+	})
+}
@@ -0,0 +1,282 @@
+// Package cache provides a guard that caches the values returned by the process.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// WithKey is the same as guard.WithKey.
+func WithKey(ctx context.Context, key string) context.Context {
+	return guard.WithKey(ctx, key)
+}
+
+// KeyFromContext is the same as guard.KeyFromContext.
+func KeyFromContext(ctx context.Context) (string, bool) {
+	return guard.KeyFromContext(ctx)
+}
+
+// Cache is a guard.Guard with additional methods.
+type Cache interface {
+	guard.Guard
+
+	// Delete removes the value of the key.
+	Delete(key string)
+
+	// Purge removes all values.
+	Purge()
+}
+
+// New creates a new Cache that keeps the value of the successful process
+// for ttl, and returns it to the following processes with the same key
+// without running them.
+//
+// The value is passed through guard.RunValue.
+// The process without a key, or run without guard.RunValue, runs as it is.
+//
+// After ttl, the value is served as a stale value while it is refreshed in
+// the background for the duration set by WithStaleWhileRevalidate,
+// and served when the process fails for the duration set by WithStaleIfError.
+// The entries that cannot be served any more are removed when a value is put,
+// and the number of entries can be limited by WithMaxEntries.
+//
+// The key is shared with singleflight, so that the cache composed with
+// singleflight coalesces the processes refreshing the same key.
+func New(ttl time.Duration, options ...Option) Cache {
+	c := &cache{
+		ttl:        ttl,
+		keyFunc:    KeyFromContext,
+		clock:      guard.SystemClock,
+		entries:    make(map[string]*entry),
+		expiry:     list.New(),
+		refreshing: make(map[string]bool),
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// Option is the optional parameter for New.
+type Option func(*cache)
+
+// WithStaleWhileRevalidate set the duration after ttl during which the stale
+// value is returned while it is refreshed in the background.
+// The default is 0.
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return Option(func(c *cache) {
+		c.staleWhileRevalidate = d
+	})
+}
+
+// WithStaleIfError set the duration after ttl during which the stale
+// value is returned when the process fails, e.g. when the circuit breaker is open.
+// The default is 0.
+func WithStaleIfError(d time.Duration) Option {
+	return Option(func(c *cache) {
+		c.staleIfError = d
+	})
+}
+
+// WithKeyFunc set the function that derives the key from the context.
+// The default is KeyFromContext.
+func WithKeyFunc(f func(ctx context.Context) (string, bool)) Option {
+	return Option(func(c *cache) {
+		c.keyFunc = f
+	})
+}
+
+// WithMaxEntries set the maximum number of entries.
+// When the cache is full, the entry that expires first is evicted.
+// The default is 0, which means unlimited.
+func WithMaxEntries(n int) Option {
+	return Option(func(c *cache) {
+		c.maxEntries = n
+	})
+}
+
+// WithPanicHandler set the function called with the panic occurred in the
+// background refresh, which cannot be returned to the caller.
+// The default is nil, which panics again and crashes the process
+// as the panic in any other goroutine does.
+func WithPanicHandler(f func(ctx context.Context, reason interface{})) Option {
+	return Option(func(c *cache) {
+		c.panicHandler = f
+	})
+}
+
+// WithClock set the clock used by the cache.
+// The default is guard.SystemClock.
+func WithClock(clock guard.Clock) Option {
+	return Option(func(c *cache) {
+		c.clock = clock
+	})
+}
+
+type entry struct {
+	key      string
+	value    interface{}
+	expireAt time.Time
+	elem     *list.Element
+}
+
+type cache struct {
+	ttl                  time.Duration
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+	maxEntries           int
+	keyFunc              func(context.Context) (string, bool)
+	panicHandler         func(context.Context, interface{})
+	clock                guard.Clock
+
+	entries    map[string]*entry
+	expiry     *list.List // the entries in the order of expireAt, which is the order of put.
+	refreshing map[string]bool
+	mu         sync.Mutex
+}
+
+func (c *cache) Run(ctx context.Context, f func(context.Context) error) error {
+	key, ok := c.keyFunc(ctx)
+	r := guard.ResultFromContext(ctx)
+	if !ok || r == nil {
+		return f(ctx)
+	}
+
+	now := c.clock.Now()
+	e := c.get(key, now)
+	switch {
+	case e == nil:
+	case now.Before(e.expireAt):
+		r.SetValue(e.value)
+		return nil
+	case now.Before(e.expireAt.Add(c.staleWhileRevalidate)):
+		r.SetValue(e.value)
+		c.refresh(ctx, key, f)
+		return nil
+	}
+
+	err := f(ctx)
+	if err == nil {
+		c.put(key, r.Value())
+		return nil
+	}
+
+	if e != nil && c.clock.Now().Before(e.expireAt.Add(c.staleIfError)) {
+		r.SetValue(e.value)
+		return nil
+	}
+	return err
+}
+
+// refresh runs f in the background to update the value of the key.
+func (c *cache) refresh(ctx context.Context, key string, f func(context.Context) error) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	r := &guard.Result{}
+	ctx = guard.WithResult(guard.Detach(ctx), r)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		if c.panicHandler != nil {
+			defer func() {
+				if reason := recover(); reason != nil {
+					c.panicHandler(ctx, reason)
+				}
+			}()
+		}
+		if err := f(ctx); err == nil {
+			c.put(key, r.Value())
+		}
+	}()
+}
+
+// get returns the entry of the key that can be still served.
+func (c *cache) get(key string, now time.Time) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if !c.servable(e, now) {
+		c.remove(e)
+		return nil
+	}
+	return e
+}
+
+// servable reports whether e can be still served at now.
+func (c *cache) servable(e *entry, now time.Time) bool {
+	stale := c.staleWhileRevalidate
+	if c.staleIfError > stale {
+		stale = c.staleIfError
+	}
+	return now.Before(e.expireAt.Add(stale))
+}
+
+func (c *cache) put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.sweep(now)
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		// evict the entry that expires first.
+		c.remove(c.expiry.Front().Value.(*entry))
+	}
+	e := &entry{key: key, value: value, expireAt: now.Add(c.ttl)}
+	e.elem = c.expiry.PushBack(e)
+	c.entries[key] = e
+}
+
+// sweep removes the entries that cannot be served any more.
+// c.mu must be held.
+func (c *cache) sweep(now time.Time) {
+	for elem := c.expiry.Front(); elem != nil; elem = c.expiry.Front() {
+		e := elem.Value.(*entry)
+		if c.servable(e, now) {
+			return
+		}
+		c.remove(e)
+	}
+}
+
+// remove removes e from the cache.
+// c.mu must be held.
+func (c *cache) remove(e *entry) {
+	delete(c.entries, e.key)
+	c.expiry.Remove(e.elem)
+}
+
+func (c *cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+}
+
+func (c *cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*entry)
+	c.expiry.Init()
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/guardtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	ctx := WithKey(context.Background(), "key")
+
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(time.Minute)
+
+		_, err := guard.RunValue(ctx, g, func(_ context.Context) (interface{}, error) {
+			return nil, errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("value should be cached for ttl", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Time{})
+		g := New(time.Minute, WithClock(clock))
+
+		count := 0
+		f := func(_ context.Context) (interface{}, error) {
+			count++
+			return count, nil
+		}
+
+		v, _ := guard.RunValue(ctx, g, f)
+		assert.Equal(1, v)
+		clock.Add(59 * time.Second)
+		v, _ = guard.RunValue(ctx, g, f)
+		assert.Equal(1, v)
+
+		v, _ = guard.RunValue(WithKey(context.Background(), "other"), g, f)
+		assert.Equal(2, v)
+
+		clock.Add(time.Second)
+		v, _ = guard.RunValue(ctx, g, f)
+		assert.Equal(3, v)
+
+		g.Delete("key")
+		v, _ = guard.RunValue(ctx, g, f)
+		assert.Equal(4, v)
+
+		g.Purge()
+		v, _ = guard.RunValue(ctx, g, f)
+		assert.Equal(5, v)
+	})
+
+	t.Run("process without key or value should not be cached", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(time.Minute)
+
+		count := 0
+		f := func(_ context.Context) error {
+			count++
+			return nil
+		}
+		g.Run(ctx, f)
+		g.Run(ctx, f)
+		guard.RunValue(context.Background(), g, func(_ context.Context) (interface{}, error) {
+			count++
+			return nil, nil
+		})
+
+		assert.Equal(3, count)
+	})
+
+	t.Run("stale value should be served while it is refreshed", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Time{})
+		g := New(time.Minute, WithClock(clock), WithStaleWhileRevalidate(time.Minute))
+
+		var count int32
+		refreshed := make(chan struct{}, 1)
+		f := func(_ context.Context) (interface{}, error) {
+			n := atomic.AddInt32(&count, 1)
+			if n > 1 {
+				refreshed <- struct{}{}
+			}
+			return n, nil
+		}
+
+		guard.RunValue(ctx, g, f)
+		clock.Add(90 * time.Second)
+
+		v, err := guard.RunValue(ctx, g, f)
+		assert.NoError(err)
+		assert.Equal(int32(1), v)
+
+		<-refreshed
+		time.Sleep(10 * time.Millisecond)
+		v, _ = guard.RunValue(ctx, g, f)
+		assert.Equal(int32(2), v)
+	})
+
+	t.Run("stale value should be served when the process fails", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Time{})
+		g := New(time.Minute, WithClock(clock), WithStaleIfError(time.Hour))
+
+		guard.RunValue(ctx, g, func(_ context.Context) (interface{}, error) {
+			return "stale", nil
+		})
+		clock.Add(30 * time.Minute)
+
+		fail := func(_ context.Context) (interface{}, error) {
+			return nil, errors.New("test error")
+		}
+		v, err := guard.RunValue(ctx, g, fail)
+		assert.NoError(err)
+		assert.Equal("stale", v)
+
+		clock.Add(time.Hour)
+		_, err = guard.RunValue(ctx, g, fail)
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("panic in the background refresh should be reported to the handler", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Time{})
+		reported := make(chan interface{}, 1)
+		g := New(time.Minute, WithClock(clock), WithStaleWhileRevalidate(time.Minute),
+			WithPanicHandler(func(_ context.Context, reason interface{}) {
+				reported <- reason
+			}))
+
+		guard.RunValue(ctx, g, func(_ context.Context) (interface{}, error) {
+			return "stale", nil
+		})
+		clock.Add(90 * time.Second)
+
+		v, err := guard.RunValue(ctx, g, func(_ context.Context) (interface{}, error) {
+			panic("boom")
+		})
+		assert.NoError(err)
+		assert.Equal("stale", v)
+		assert.Equal("boom", <-reported)
+	})
+
+	t.Run("entry that expires first should be evicted when the cache is full", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Time{})
+		g := New(time.Minute, WithClock(clock), WithMaxEntries(2))
+
+		put := func(key string) {
+			guard.RunValue(WithKey(context.Background(), key), g, func(_ context.Context) (interface{}, error) {
+				return key, nil
+			})
+			clock.Add(time.Second)
+		}
+		put("a")
+		put("b")
+		put("c")
+
+		var count int
+		for _, key := range []string{"b", "c", "a"} {
+			guard.RunValue(WithKey(context.Background(), key), g, func(_ context.Context) (interface{}, error) {
+				count++
+				return key, nil
+			})
+		}
+		assert.Equal(1, count)
+		assert.Len(g.(*cache).entries, 2)
+	})
+
+	t.Run("expired entries should be swept", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Time{})
+		g := New(time.Minute, WithClock(clock))
+
+		for i := 0; i < 120; i++ {
+			guard.RunValue(WithKey(context.Background(), fmt.Sprint(i)), g, func(_ context.Context) (interface{}, error) {
+				return i, nil
+			})
+			clock.Add(time.Second)
+		}
+
+		// the entries put in the last minute remain.
+		assert.Len(g.(*cache).entries, 60)
+		assert.Equal(60, g.(*cache).expiry.Len())
+	})
+
+	t.Run("key should be shared with guard.WithKey", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key, ok := KeyFromContext(guard.WithKey(context.Background(), "key"))
+		assert.True(ok)
+		assert.Equal("key", key)
+	})
+}
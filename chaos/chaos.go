@@ -0,0 +1,203 @@
+// Package chaos provides a guard that injects faults to test the resilience of the process.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// ErrInjected is a error that is injected when no error is given to WithError.
+var ErrInjected = errors.New("injected fault")
+
+// Chaos is a guard.Guard that injects faults.
+// It is disabled when created, and does nothing but runs the function until Enable is called,
+// so that it can be shipped in production binaries.
+type Chaos interface {
+	guard.Guard
+
+	// Enable starts injecting faults.
+	Enable()
+
+	// Disable stops injecting faults.
+	Disable()
+
+	// Enabled reports whether the faults are injected.
+	Enabled() bool
+}
+
+// New creates a new Chaos injecting the faults given by options.
+//
+// The faults are injected in the following order, each with its own probability.
+//
+//  1. Latency is added before the function runs.
+//  2. The context passed to the function is cancelled.
+//  3. The function is replaced with a panic.
+//  4. The function is replaced with an error.
+func New(options ...Option) Chaos {
+	c := &chaos{
+		clock:      guard.SystemClock,
+		randomizer: guard.NewRandomizer(),
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+type chaos struct {
+	enabled int32
+
+	latency     time.Duration
+	latencyRate float64
+	cancelRate  float64
+	panicValue  interface{}
+	panicRate   float64
+	err         error
+	errorRate   float64
+	keys        []interface{}
+	clock       guard.Clock
+	randomizer  guard.Randomizer
+}
+
+func (c *chaos) Enable() {
+	atomic.StoreInt32(&c.enabled, 1)
+}
+
+func (c *chaos) Disable() {
+	atomic.StoreInt32(&c.enabled, 0)
+}
+
+func (c *chaos) Enabled() bool {
+	return atomic.LoadInt32(&c.enabled) == 1
+}
+
+func (c *chaos) Run(ctx context.Context, f func(context.Context) error) error {
+	if !c.Enabled() || !c.inScope(ctx) {
+		return f(ctx)
+	}
+
+	if c.happen(c.latencyRate) {
+		if err := c.sleep(ctx, c.latency); err != nil {
+			return err
+		}
+	}
+	if c.happen(c.cancelRate) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+	if c.happen(c.panicRate) {
+		panic(c.panicValue)
+	}
+	if c.happen(c.errorRate) {
+		return c.err
+	}
+	return f(ctx)
+}
+
+// inScope reports whether the context has any of the keys.
+// All contexts are in scope if no key is given.
+func (c *chaos) inScope(ctx context.Context) bool {
+	if len(c.keys) == 0 {
+		return true
+	}
+	for _, key := range c.keys {
+		if ctx.Value(key) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *chaos) happen(p float64) bool {
+	return p > 0 && c.randomizer.Float64() < p
+}
+
+func (c *chaos) sleep(ctx context.Context, d time.Duration) error {
+	t := c.clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
+
+// Option is the optional parameter for New.
+type Option func(*chaos)
+
+// WithLatency adds latency d before the function with probability p.
+func WithLatency(p float64, d time.Duration) Option {
+	return Option(func(c *chaos) {
+		c.latencyRate = p
+		c.latency = d
+	})
+}
+
+// WithCancel cancels the context passed to the function with probability p.
+func WithCancel(p float64) Option {
+	return Option(func(c *chaos) {
+		c.cancelRate = p
+	})
+}
+
+// WithPanic panics with v instead of running the function with probability p.
+func WithPanic(p float64, v interface{}) Option {
+	return Option(func(c *chaos) {
+		c.panicRate = p
+		c.panicValue = v
+	})
+}
+
+// WithError returns err instead of running the function with probability p.
+// ErrInjected is returned if err is nil.
+func WithError(p float64, err error) Option {
+	return Option(func(c *chaos) {
+		if err == nil {
+			err = ErrInjected
+		}
+		c.errorRate = p
+		c.err = err
+	})
+}
+
+// WithContextKey limits the faults to the contexts that have a value for key.
+// If it is given multiple times, the contexts that have any of the keys are in scope.
+func WithContextKey(key interface{}) Option {
+	return Option(func(c *chaos) {
+		c.keys = append(c.keys, key)
+	})
+}
+
+// WithEnabled set whether the faults are injected from the start.
+// The default is false.
+func WithEnabled(enabled bool) Option {
+	return Option(func(c *chaos) {
+		if enabled {
+			c.enabled = 1
+		} else {
+			c.enabled = 0
+		}
+	})
+}
+
+// WithClock set the clock used to add latency.
+// The default is guard.SystemClock.
+func WithClock(clock guard.Clock) Option {
+	return Option(func(c *chaos) {
+		c.clock = clock
+	})
+}
+
+// WithRandomizer set the randomizer used to decide whether to inject faults.
+// The default is guard.NewRandomizer().
+func WithRandomizer(r guard.Randomizer) Option {
+	return Option(func(c *chaos) {
+		c.randomizer = r
+	})
+}
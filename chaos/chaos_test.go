@@ -0,0 +1,101 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/guardtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testKey struct{}
+
+func TestChaos(t *testing.T) {
+	t.Run("disabled chaos should do nothing", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := New(WithError(1, nil), WithPanic(1, "test"))
+
+		r := guardtest.NewRecorder()
+		err := c.Run(context.Background(), r.Func("f", guardtest.Succeed))
+
+		assert.False(c.Enabled())
+		assert.NoError(err)
+		guardtest.AssertCalls(t, r, "f")
+	})
+
+	t.Run("faults should be injected when enabled", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := New(WithError(1, errors.New("test error")), WithEnabled(true))
+
+		err := c.Run(context.Background(), guardtest.Succeed)
+		assert.EqualError(err, "test error")
+
+		c.Disable()
+		assert.NoError(c.Run(context.Background(), guardtest.Succeed))
+		c.Enable()
+		assert.EqualError(c.Run(context.Background(), guardtest.Succeed), "test error")
+
+		c = New(WithError(1, nil), WithEnabled(true))
+		assert.Equal(ErrInjected, c.Run(context.Background(), guardtest.Succeed))
+	})
+
+	t.Run("faults should follow the probabilities", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := New(
+			WithError(0.5, nil),
+			WithEnabled(true),
+			WithRandomizer(guard.NewSeededRandomizer(1)),
+		)
+
+		failed := 0
+		for i := 0; i < 1000; i++ {
+			if c.Run(context.Background(), guardtest.Succeed) != nil {
+				failed++
+			}
+		}
+		assert.InDelta(500, failed, 50)
+	})
+
+	t.Run("panic and cancel should be injected", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.PanicsWithValue("test", func() {
+			New(WithPanic(1, "test"), WithEnabled(true)).Run(context.Background(), guardtest.Succeed)
+		})
+
+		err := New(WithCancel(1), WithEnabled(true)).Run(context.Background(), guardtest.Hang)
+		assert.Equal(context.Canceled, err)
+	})
+
+	t.Run("latency should be added", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+		c := New(WithLatency(1, time.Second), WithEnabled(true), WithClock(clock))
+
+		done := make(chan error)
+		go func() {
+			done <- c.Run(context.Background(), guardtest.Succeed)
+		}()
+
+		clock.BlockUntil(1)
+		clock.Add(time.Second)
+		assert.NoError(<-done)
+	})
+
+	t.Run("faults should be limited to the contexts with the key", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := New(WithError(1, nil), WithContextKey(testKey{}), WithEnabled(true))
+
+		assert.NoError(c.Run(context.Background(), guardtest.Succeed))
+		ctx := context.WithValue(context.Background(), testKey{}, true)
+		assert.Equal(ErrInjected, c.Run(ctx, guardtest.Succeed))
+	})
+}
@@ -22,20 +22,36 @@ type CircuitBreaker interface {
 }
 
 // New creates a new guard.Guard with capability of circuit breaker.
-func New(window Window, threashold float64, backoff guard.Backoff) CircuitBreaker {
+// If the backoff stops, the circuit breaker keeps trying "half-open" at the last interval
+// (or immediately if the backoff stops at first) until it is closed, which resets the backoff.
+func New(window Window, threashold float64, backoff guard.Backoff, options ...Option) CircuitBreaker {
 	window.Reset()
 	cb := &circuitBreaker{
-		window,
-		threashold,
-		close,
-		backoff.Reset(),
+		window:     window,
+		threashold: threashold,
+		state:      close,
+		backoff:    backoff.Reset(),
+		clock:      guard.SystemClock,
 
-		[]chan<- StateChange{},
-		sync.RWMutex{},
+		subscribers: []chan<- StateChange{},
+	}
+	for _, o := range options {
+		o(cb)
 	}
 	return cb
 }
 
+// Option is the optional parameter for New.
+type Option func(*circuitBreaker)
+
+// WithClock set the clock used to wait for the intervals before "half-open".
+// The default is guard.SystemClock.
+func WithClock(c guard.Clock) Option {
+	return Option(func(cb *circuitBreaker) {
+		cb.clock = c
+	})
+}
+
 const (
 	close int32 = iota
 	halfopen
@@ -50,6 +66,9 @@ type circuitBreaker struct {
 	threashold float64
 	state      int32
 	backoff    guard.Backoff
+	opened     int64 // the number of consecutive opens, used with guard.AttemptBackoff.
+	last       int64 // the last interval, used after the backoff stops.
+	clock      guard.Clock
 
 	subscribers []chan<- StateChange
 	mu          sync.RWMutex
@@ -125,15 +144,37 @@ func (cb *circuitBreaker) open(state int32) {
 		sc = HalfOpenToOpen
 	}
 	if cb.change(state, open, sc) {
-		time.AfterFunc(cb.backoff.NextInterval(), func() {
+		cb.clock.AfterFunc(cb.nextInterval(), func() {
 			cb.change(open, halfopen, OpenToHalfOpen)
 		})
 	}
 }
 
+func (cb *circuitBreaker) nextInterval() time.Duration {
+	n := atomic.AddInt64(&cb.opened, 1)
+	var (
+		d  time.Duration
+		ok bool
+	)
+	if a, isAttempt := cb.backoff.(guard.AttemptBackoff); isAttempt {
+		d, ok = a.Interval(int(n))
+	} else {
+		d, ok = guard.Next(cb.backoff)
+	}
+	if !ok {
+		return time.Duration(atomic.LoadInt64(&cb.last))
+	}
+	atomic.StoreInt64(&cb.last, int64(d))
+	return d
+}
+
 func (cb *circuitBreaker) close() {
 	if cb.change(halfopen, close, HalfOpenToClose) {
-		cb.backoff = cb.backoff.Reset()
+		atomic.StoreInt64(&cb.opened, 0)
+		atomic.StoreInt64(&cb.last, 0)
+		if _, ok := cb.backoff.(guard.AttemptBackoff); !ok {
+			cb.backoff = cb.backoff.Reset()
+		}
 		cb.window.Reset()
 	}
 }
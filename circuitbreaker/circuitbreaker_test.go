@@ -0,0 +1,42 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/guardtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("circuit breaker should keep trying half-open after the backoff stops", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+		backoff := guard.NewMaxRetriesBackoff(guard.NewConstantBackoff(time.Second), 1)
+		cb := New(NewCountBaseWindow(1), 0.5, backoff, WithClock(clock))
+		events := cb.Subscribe()
+
+		fail := guardtest.Fail(errors.New("test error"))
+
+		assert.EqualError(cb.Run(context.Background(), fail), "test error")
+		assert.Equal(CloseToOpen, <-events)
+		assert.Equal(ErrCircuitBreakerOpen, cb.Run(context.Background(), guardtest.Succeed))
+
+		// the backoff stops after the first interval.
+		for i := 0; i < 3; i++ {
+			clock.Add(time.Second)
+			assert.Equal(OpenToHalfOpen, <-events)
+			assert.EqualError(cb.Run(context.Background(), fail), "test error")
+			assert.Equal(HalfOpenToOpen, <-events)
+		}
+
+		clock.Add(time.Second)
+		assert.Equal(OpenToHalfOpen, <-events)
+		assert.NoError(cb.Run(context.Background(), guardtest.Succeed))
+		assert.Equal(HalfOpenToClose, <-events)
+	})
+}
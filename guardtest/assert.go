@@ -0,0 +1,83 @@
+package guardtest
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TestingT is the subset of *testing.T used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type helper interface {
+	Helper()
+}
+
+// AssertCalls asserts that the names of the calls recorded by r are names in order.
+func AssertCalls(t TestingT, r *Recorder, names ...string) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	actual := r.Names()
+	if len(actual) == 0 && len(names) == 0 {
+		return true
+	}
+	if !reflect.DeepEqual(actual, names) {
+		return fail(t, "calls", names, actual)
+	}
+	return true
+}
+
+// AssertCallCount asserts that r recorded n calls with name.
+func AssertCallCount(t TestingT, r *Recorder, name string, n int) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	if actual := r.Count(name); actual != n {
+		return fail(t, fmt.Sprintf("number of calls of %q", name), n, actual)
+	}
+	return true
+}
+
+// AssertErrors asserts that the errors returned by the calls with name are errs in order.
+// The calls that have not returned yet or panicked are ignored.
+func AssertErrors(t TestingT, r *Recorder, name string, errs ...error) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	var actual []error
+	for _, c := range r.Calls() {
+		if c.Name == name && c.Done && c.Panic == nil {
+			actual = append(actual, c.Err)
+		}
+	}
+	if len(actual) == 0 && len(errs) == 0 {
+		return true
+	}
+	if !reflect.DeepEqual(actual, errs) {
+		return fail(t, fmt.Sprintf("errors of %q", name), errs, actual)
+	}
+	return true
+}
+
+// AssertRequested asserts that the intervals requested from b are intervals in order.
+func AssertRequested(t TestingT, b *Backoff, intervals ...time.Duration) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	actual := b.Requested()
+	if len(actual) == 0 && len(intervals) == 0 {
+		return true
+	}
+	if !reflect.DeepEqual(actual, intervals) {
+		return fail(t, "requested intervals", intervals, actual)
+	}
+	return true
+}
+
+func fail(t TestingT, what string, expected, actual interface{}) bool {
+	t.Errorf("unexpected %s:\nexpected: %v\nactual  : %v", what, expected, actual)
+	return false
+}
@@ -0,0 +1,78 @@
+package guardtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// Backoff is a fake guard.Backoff that returns the given intervals in order
+// and records the intervals requested by the guard.
+// It returns guard.Stop after the intervals are exhausted.
+//
+// The Backoff created by Reset restarts the intervals from the first one,
+// and shares the records with the original.
+type Backoff struct {
+	intervals []time.Duration
+	record    *backoffRecord
+
+	idx int
+	mu  sync.Mutex
+}
+
+type backoffRecord struct {
+	requested []time.Duration
+	resets    int
+	mu        sync.Mutex
+}
+
+// NewBackoff creates a new Backoff returning intervals.
+func NewBackoff(intervals ...time.Duration) *Backoff {
+	return &Backoff{
+		intervals: append([]time.Duration(nil), intervals...),
+		record:    &backoffRecord{},
+	}
+}
+
+// NextInterval implements guard.Backoff.
+func (b *Backoff) NextInterval() time.Duration {
+	b.mu.Lock()
+	d := guard.Stop
+	if b.idx < len(b.intervals) {
+		d = b.intervals[b.idx]
+		b.idx++
+	}
+	b.mu.Unlock()
+
+	b.record.mu.Lock()
+	defer b.record.mu.Unlock()
+	b.record.requested = append(b.record.requested, d)
+	return d
+}
+
+// Reset implements guard.Backoff.
+func (b *Backoff) Reset() guard.Backoff {
+	b.record.mu.Lock()
+	defer b.record.mu.Unlock()
+	b.record.resets++
+	return &Backoff{
+		intervals: b.intervals,
+		record:    b.record,
+	}
+}
+
+// Requested returns the intervals returned by NextInterval so far,
+// including guard.Stop.
+func (b *Backoff) Requested() []time.Duration {
+	b.record.mu.Lock()
+	defer b.record.mu.Unlock()
+	return append([]time.Duration(nil), b.record.requested...)
+}
+
+// Resets returns the number of times Reset was called.
+func (b *Backoff) Resets() int {
+	b.record.mu.Lock()
+	defer b.record.mu.Unlock()
+	return b.record.resets
+}
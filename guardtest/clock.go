@@ -0,0 +1,208 @@
+// Package guardtest provides utilities for testing the code using guard.
+package guardtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// Clock is a fake guard.Clock whose time advances only by Add or Set.
+// The timers created by the clock fire in the order of their time,
+// and the functions passed to AfterFunc are called synchronously by Add or Set,
+// so that the tests using Clock are deterministic.
+type Clock struct {
+	now    time.Time
+	timers []*timer
+	mu     sync.Mutex
+	cond   *sync.Cond
+}
+
+// NewClock creates a new Clock starting at now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now implements guard.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer implements guard.Clock.
+// The timer fires immediately if d <= 0.
+func (c *Clock) NewTimer(d time.Duration) guard.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &timer{clock: c, c: make(chan time.Time, 1), at: c.now.Add(d)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.add(t)
+	return t
+}
+
+// AfterFunc implements guard.Clock.
+// f is called synchronously if d <= 0.
+func (c *Clock) AfterFunc(d time.Duration, f func()) guard.Timer {
+	c.mu.Lock()
+	t := &timer{clock: c, f: f, at: c.now.Add(d)}
+	if d <= 0 {
+		c.mu.Unlock()
+		f()
+		return t
+	}
+	c.add(t)
+	c.mu.Unlock()
+	return t
+}
+
+func (c *Clock) add(t *timer) {
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+}
+
+// Add advances the clock by d, firing the timers on the way.
+func (c *Clock) Add(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set sets the clock to now, firing the timers on the way.
+// While firing a timer, Now returns the time of the timer.
+func (c *Clock) Set(now time.Time) {
+	for c.fire(now) {
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Step advances the clock to the earliest pending timer and fires only the timer.
+// It returns false if there is no pending timer.
+func (c *Clock) Step() bool {
+	next, ok := c.Next()
+	if !ok {
+		return false
+	}
+	return c.fire(next)
+}
+
+// fire fires the earliest timer that fires at or before now,
+// and reports whether a timer is fired.
+func (c *Clock) fire(now time.Time) bool {
+	c.mu.Lock()
+	t := c.next(now)
+	if t == nil {
+		c.mu.Unlock()
+		return false
+	}
+	c.remove(t)
+	if t.at.After(c.now) {
+		c.now = t.at
+	}
+	fired := c.now
+	c.mu.Unlock()
+
+	if t.f != nil {
+		t.f()
+	} else {
+		t.c <- fired
+	}
+	return true
+}
+
+// Next returns the time of the earliest pending timer, or false if there is no timer.
+func (c *Clock) Next() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var next *timer
+	for _, t := range c.timers {
+		if next == nil || t.at.Before(next.at) {
+			next = t
+		}
+	}
+	if next == nil {
+		return time.Time{}, false
+	}
+	return next.at, true
+}
+
+// Timers returns the number of pending timers.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Sleepers returns the number of pending timers created by NewTimer.
+// Unlike Timers, it does not count the timers created by AfterFunc,
+// so that it approximates the number of goroutines waiting on the clock.
+func (c *Clock) Sleepers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.timers {
+		if t.f == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// BlockUntil blocks until the number of pending timers becomes at least n.
+// It is useful to wait for the goroutine under test to start waiting on the clock.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// next returns the earliest timer that fires at or before now.
+// The timers at the same time are fired in the order of creation.
+func (c *Clock) next(now time.Time) *timer {
+	var next *timer
+	for _, t := range c.timers {
+		if t.at.After(now) {
+			continue
+		}
+		if next == nil || t.at.Before(next.at) {
+			next = t
+		}
+	}
+	return next
+}
+
+// remove removes t from the pending timers and reports whether t was pending.
+func (c *Clock) remove(t *timer) bool {
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type timer struct {
+	clock *Clock
+	c     chan time.Time
+	f     func()
+	at    time.Time
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
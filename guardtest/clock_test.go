@@ -0,0 +1,99 @@
+package guardtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("timers should fire in order of time", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := NewClock(start)
+
+		var fired []time.Duration
+		c.AfterFunc(2*time.Second, func() {
+			fired = append(fired, c.Now().Sub(start))
+		})
+		c.AfterFunc(time.Second, func() {
+			fired = append(fired, c.Now().Sub(start))
+			c.AfterFunc(500*time.Millisecond, func() {
+				fired = append(fired, c.Now().Sub(start))
+			})
+		})
+		timer := c.NewTimer(3 * time.Second)
+
+		c.Add(2 * time.Second)
+
+		assert.Equal([]time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second}, fired)
+		assert.Equal(start.Add(2*time.Second), c.Now())
+		assert.Equal(1, c.Timers())
+
+		next, ok := c.Next()
+		assert.True(ok)
+		assert.Equal(start.Add(3*time.Second), next)
+
+		c.Add(time.Second)
+		assert.Equal(start.Add(3*time.Second), <-timer.C())
+		assert.Equal(0, c.Timers())
+	})
+
+	t.Run("stopped timer should not fire", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := NewClock(start)
+
+		called := false
+		timer := c.AfterFunc(time.Second, func() {
+			called = true
+		})
+
+		assert.True(timer.Stop())
+		assert.False(timer.Stop())
+		c.Add(time.Second)
+		assert.False(called)
+	})
+
+	t.Run("BlockUntil should wait for the timer", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := NewClock(start)
+
+		done := make(chan struct{})
+		go func() {
+			<-c.NewTimer(time.Second).C()
+			close(done)
+		}()
+
+		c.BlockUntil(1)
+		c.Add(time.Second)
+		<-done
+		assert.Equal(0, c.Timers())
+	})
+
+	t.Run("Step should fire only the earliest timer", func(t *testing.T) {
+		assert := assert.New(t)
+
+		c := NewClock(start)
+
+		var fired []int
+		c.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+		c.AfterFunc(time.Second, func() { fired = append(fired, 2) })
+		c.NewTimer(2 * time.Second)
+
+		assert.Equal(1, c.Sleepers())
+		assert.True(c.Step())
+		assert.Equal([]int{1}, fired)
+		assert.True(c.Step())
+		assert.Equal([]int{1, 2}, fired)
+		assert.Equal(start.Add(time.Second), c.Now())
+		assert.True(c.Step())
+		assert.Equal(start.Add(2*time.Second), c.Now())
+		assert.Equal(0, c.Sleepers())
+		assert.False(c.Step())
+	})
+}
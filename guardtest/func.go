@@ -0,0 +1,57 @@
+package guardtest
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Succeed is a function that always succeeds.
+func Succeed(_ context.Context) error {
+	return nil
+}
+
+// Hang is a function that blocks until the context is done
+// and returns the error of the context.
+func Hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Fail returns a function that always fails with err.
+func Fail(err error) func(context.Context) error {
+	return func(_ context.Context) error {
+		return err
+	}
+}
+
+// FailTimes returns a function that fails with err for the first n calls
+// and succeeds after that.
+func FailTimes(n int, err error) func(context.Context) error {
+	var count int64
+	return func(_ context.Context) error {
+		if atomic.AddInt64(&count, 1) <= int64(n) {
+			return err
+		}
+		return nil
+	}
+}
+
+// Sequence returns a function that returns errs in order,
+// and succeeds after they are exhausted.
+func Sequence(errs ...error) func(context.Context) error {
+	var count int64
+	return func(_ context.Context) error {
+		n := atomic.AddInt64(&count, 1)
+		if n <= int64(len(errs)) {
+			return errs[n-1]
+		}
+		return nil
+	}
+}
+
+// Panic returns a function that panics with v.
+func Panic(v interface{}) func(context.Context) error {
+	return func(_ context.Context) error {
+		panic(v)
+	}
+}
@@ -0,0 +1,129 @@
+package guardtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/stretchr/testify/assert"
+)
+
+type testT struct {
+	errors []string
+}
+
+func (t *testT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("calls should be recorded in order", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := NewRecorder()
+		g := guard.Compose(r.Guard("outer"), r.Guard("inner"))
+
+		err := g.Run(context.Background(), r.Func("f", Fail(errors.New("test error"))))
+
+		assert.EqualError(err, "test error")
+		assert.True(AssertCalls(t, r, "outer", "inner", "f"))
+		assert.True(AssertCallCount(t, r, "f", 1))
+		assert.True(AssertErrors(t, r, "inner", errors.New("test error")))
+
+		r.Reset()
+		assert.True(AssertCalls(t, r))
+	})
+
+	t.Run("panic should be recorded and propagated", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := NewRecorder()
+
+		assert.PanicsWithValue("test", func() {
+			r.Guard("guard").Run(context.Background(), Panic("test"))
+		})
+
+		calls := r.Calls()
+		assert.Len(calls, 1)
+		assert.True(calls[0].Done)
+		assert.Equal("test", calls[0].Panic)
+		assert.True(AssertErrors(t, r, "guard"))
+	})
+
+	t.Run("assertions should report the difference", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := NewRecorder()
+		r.Func("f", Succeed)(context.Background())
+
+		tt := &testT{}
+		assert.False(AssertCalls(tt, r, "g"))
+		assert.False(AssertCallCount(tt, r, "f", 2))
+		assert.False(AssertErrors(tt, r, "f", errors.New("test error")))
+		assert.Equal([]string{
+			"unexpected calls:\nexpected: [g]\nactual  : [f]",
+			"unexpected number of calls of \"f\":\nexpected: 2\nactual  : 1",
+			"unexpected errors of \"f\":\nexpected: [test error]\nactual  : [<nil>]",
+		}, tt.errors)
+	})
+}
+
+func TestFunc(t *testing.T) {
+	t.Run("FailTimes should succeed after n failures", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := FailTimes(2, errors.New("test error"))
+
+		assert.Error(f(context.Background()))
+		assert.Error(f(context.Background()))
+		assert.NoError(f(context.Background()))
+	})
+
+	t.Run("Sequence should return the errors in order", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := Sequence(errors.New("1"), nil, errors.New("3"))
+
+		assert.EqualError(f(context.Background()), "1")
+		assert.NoError(f(context.Background()))
+		assert.EqualError(f(context.Background()), "3")
+		assert.NoError(f(context.Background()))
+	})
+
+	t.Run("Panic should panic", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.PanicsWithValue("test", func() {
+			Panic("test")(context.Background())
+		})
+	})
+
+	t.Run("Hang should return when the context is done", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.Equal(context.Canceled, Hang(ctx))
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	b := NewBackoff(time.Second, 2*time.Second)
+
+	bo := b.Reset()
+	assert.Equal(time.Second, bo.NextInterval())
+	assert.Equal(2*time.Second, bo.NextInterval())
+	assert.Equal(guard.Stop, bo.NextInterval())
+
+	bo = b.Reset()
+	assert.Equal(time.Second, bo.NextInterval())
+
+	assert.True(AssertRequested(t, b, time.Second, 2*time.Second, guard.Stop, time.Second))
+	assert.Equal(2, b.Resets())
+}
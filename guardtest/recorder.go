@@ -0,0 +1,114 @@
+package guardtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/morikuni/guard"
+)
+
+// Call is a record of a call of a Guard or a function.
+type Call struct {
+	// Name is the name given to the Guard or the function.
+	Name string
+
+	// Err is the error returned by the call.
+	// It is nil until the call returns, or if the call panicked.
+	Err error
+
+	// Panic is the value with which the call panicked.
+	// It is nil if the call did not panic.
+	Panic interface{}
+
+	// Done reports whether the call has returned or panicked.
+	Done bool
+}
+
+// Recorder records the calls of Guards and functions in the order they started.
+// It is safe for concurrent use.
+type Recorder struct {
+	calls []Call
+	mu    sync.Mutex
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Guard returns a guard.Guard that records its calls with name
+// and runs the function as is.
+func (r *Recorder) Guard(name string) guard.Guard {
+	return guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
+		return r.Func(name, f)(ctx)
+	})
+}
+
+// Func returns a function that records its calls with name and calls f.
+// If f panics, the panic is recorded and propagated to the caller.
+func (r *Recorder) Func(name string, f func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) (err error) {
+		idx := r.start(name)
+		defer func() {
+			if p := recover(); p != nil {
+				r.finish(idx, nil, p)
+				panic(p)
+			}
+			r.finish(idx, err, nil)
+		}()
+		return f(ctx)
+	}
+}
+
+func (r *Recorder) start(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Name: name})
+	return len(r.calls) - 1
+}
+
+func (r *Recorder) finish(idx int, err error, p interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[idx].Err = err
+	r.calls[idx].Panic = p
+	r.calls[idx].Done = true
+}
+
+// Calls returns the recorded calls.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Names returns the names of the recorded calls.
+func (r *Recorder) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.calls))
+	for i, c := range r.calls {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Count returns the number of the recorded calls with name.
+func (r *Recorder) Count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, c := range r.calls {
+		if c.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset clears the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
@@ -0,0 +1,105 @@
+package panicguard
+
+import (
+	"context"
+	"sync"
+
+	"github.com/morikuni/guard"
+)
+
+// Go runs f under the guard g in a new goroutine.
+// The panic in the goroutine is recovered as PanicOccured instead of
+// crashing the process, and the result is sent to the returned channel.
+// g may be nil to run f without any other guard.
+func Go(ctx context.Context, g guard.Guard, f func(context.Context) error) <-chan error {
+	c := make(chan error, 1)
+	run := wrap(New(), g, f)
+	go func() {
+		c <- run(ctx)
+	}()
+	return c
+}
+
+// Group is a collection of goroutines running under a guard.
+type Group interface {
+	// Go runs f in a new goroutine.
+	Go(f func(context.Context) error)
+
+	// Wait waits for all goroutines to finish and returns the first error.
+	Wait() error
+
+	// Errors waits for all goroutines to finish and returns all errors
+	// in the order they occured.
+	Errors() []error
+}
+
+// NewGroup creates a new Group that runs each function under the guard g,
+// recovering its panic with options.
+// The returned context is cancelled when a function returns an error
+// or all functions finish.
+func NewGroup(ctx context.Context, g guard.Guard, options ...Option) (Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{
+		ctx:    ctx,
+		cancel: cancel,
+		pg:     New(options...),
+		g:      g,
+	}, ctx
+}
+
+type group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	pg     guard.Guard
+	g      guard.Guard
+
+	wg     sync.WaitGroup
+	errors []error
+	mu     sync.Mutex
+}
+
+func (gr *group) Go(f func(context.Context) error) {
+	run := wrap(gr.pg, gr.g, f)
+	gr.wg.Add(1)
+	go func() {
+		defer gr.wg.Done()
+		if err := run(gr.ctx); err != nil {
+			gr.mu.Lock()
+			gr.errors = append(gr.errors, err)
+			gr.mu.Unlock()
+			gr.cancel()
+		}
+	}()
+}
+
+func (gr *group) Wait() error {
+	errs := gr.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+func (gr *group) Errors() []error {
+	gr.wg.Wait()
+	gr.cancel()
+
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+	return append([]error(nil), gr.errors...)
+}
+
+// wrap returns the function that runs f under g, and recovers the panic
+// by pg, including the panic in g.
+func wrap(pg, g guard.Guard, f func(context.Context) error) func(context.Context) error {
+	if g == nil {
+		return func(ctx context.Context) error {
+			return pg.Run(ctx, f)
+		}
+	}
+	return func(ctx context.Context) error {
+		return pg.Run(ctx, func(ctx context.Context) error {
+			return g.Run(ctx, f)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package panicguard
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/morikuni/guard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGo(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		err := <-Go(context.Background(), nil, func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("panic in the goroutine should be recovered", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var called int32
+		g := guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
+			atomic.AddInt32(&called, 1)
+			return f(ctx)
+		})
+
+		err := <-Go(context.Background(), g, func(_ context.Context) error {
+			panic("test error")
+		})
+
+		assert.IsType(PanicOccured{}, err)
+		assert.EqualError(err, "panic occured: test error")
+		assert.Equal(int32(1), atomic.LoadInt32(&called))
+	})
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("nil should be returned when all functions succeed", func(t *testing.T) {
+		assert := assert.New(t)
+
+		gr, _ := NewGroup(context.Background(), nil)
+
+		var count int32
+		for i := 0; i < 10; i++ {
+			gr.Go(func(_ context.Context) error {
+				atomic.AddInt32(&count, 1)
+				return nil
+			})
+		}
+
+		assert.NoError(gr.Wait())
+		assert.Equal(int32(10), count)
+	})
+
+	t.Run("errors and panics should be collected", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var handled int32
+		gr, ctx := NewGroup(context.Background(), nil, WithHandler(func(_ context.Context, _ PanicOccured) {
+			atomic.AddInt32(&handled, 1)
+		}))
+
+		gr.Go(func(_ context.Context) error {
+			panic("test error")
+		})
+		gr.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		errs := gr.Errors()
+		if assert.Len(errs, 2) {
+			assert.EqualError(errs[0], "panic occured: test error")
+			assert.Equal(context.Canceled, errs[1])
+		}
+		assert.EqualError(gr.Wait(), "panic occured: test error")
+		assert.Equal(int32(1), handled)
+		assert.Equal(context.Canceled, ctx.Err())
+	})
+}
@@ -4,6 +4,10 @@ package panicguard
 import (
 	"context"
 	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
 
 	"github.com/morikuni/guard"
 )
@@ -12,6 +16,9 @@ import (
 type PanicOccured struct {
 	// Reason is a original panic reason.
 	Reason interface{}
+
+	stack string
+	frame runtime.Frame
 }
 
 // Error implements error.
@@ -19,14 +26,136 @@ func (po PanicOccured) Error() string {
 	return fmt.Sprintf("panic occured: %v", po.Reason)
 }
 
+// Stack returns the stack trace of the goroutine where the panic occured.
+func (po PanicOccured) Stack() []byte {
+	return []byte(po.stack)
+}
+
+// Frame returns the frame of the function that caused the panic.
+func (po PanicOccured) Frame() runtime.Frame {
+	return po.frame
+}
+
+// Format implements fmt.Formatter.
+// The verb %+v prints the stack trace in addition to the error message.
+func (po PanicOccured) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%s\n%s:%d %s\n\n%s", po.Error(), po.frame.File, po.frame.Line, po.frame.Function, po.stack)
+			return
+		}
+		io.WriteString(s, po.Error())
+	case 's':
+		io.WriteString(s, po.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", po.Error())
+	}
+}
+
 // New creates a new guard.Guard with capability of recovering panic.
-func New() guard.Guard {
+//
+// The recovered panic is passed to the handler if any, then re-panicked
+// if the repanic condition is satisfied, otherwise it is converted into
+// the error returned by the guard.
+func New(options ...Option) guard.Guard {
+	pg := &panicGuard{
+		handler: func(context.Context, PanicOccured) {},
+		repanic: func(interface{}) bool { return false },
+		errorFunc: func(po PanicOccured) error {
+			return po
+		},
+	}
+	for _, o := range options {
+		o(pg)
+	}
+
 	return guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) (err error) {
 		defer func() {
 			if e := recover(); e != nil {
-				err = PanicOccured{e}
+				po := newPanicOccured(e)
+				pg.handler(ctx, po)
+				if pg.repanic(e) {
+					panic(e)
+				}
+				err = pg.errorFunc(po)
 			}
 		}()
 		return f(ctx)
 	})
 }
+
+type panicGuard struct {
+	handler   func(context.Context, PanicOccured)
+	repanic   func(interface{}) bool
+	errorFunc func(PanicOccured) error
+}
+
+// Option is the optional parameter for New.
+type Option func(*panicGuard)
+
+// WithHandler set the function called with every recovered panic,
+// e.g. to report the crash.
+func WithHandler(f func(ctx context.Context, po PanicOccured)) Option {
+	return Option(func(pg *panicGuard) {
+		pg.handler = f
+	})
+}
+
+// WithRepanic set the condition to panic again with the original reason
+// after the handler is called.
+//
+//  panicguard.WithRepanic(func(reason interface{}) bool {
+//  	return reason == http.ErrAbortHandler
+//  })
+func WithRepanic(f func(reason interface{}) bool) Option {
+	return Option(func(pg *panicGuard) {
+		pg.repanic = f
+	})
+}
+
+// WithErrorFunc set the function to convert the recovered panic into the error.
+// The default returns PanicOccured as it is.
+func WithErrorFunc(f func(po PanicOccured) error) Option {
+	return Option(func(pg *panicGuard) {
+		pg.errorFunc = f
+	})
+}
+
+// IsRuntimeError reports whether the reason of the panic is runtime.Error.
+// It can be used with WithRepanic.
+func IsRuntimeError(reason interface{}) bool {
+	_, ok := reason.(runtime.Error)
+	return ok
+}
+
+// newPanicOccured must be called by the deferred function that recovers the panic.
+func newPanicOccured(reason interface{}) PanicOccured {
+	return PanicOccured{
+		Reason: reason,
+		stack:  string(debug.Stack()),
+		frame:  panickingFrame(),
+	}
+}
+
+// panickingFrame returns the first frame outside the runtime package
+// under runtime.gopanic.
+func panickingFrame() runtime.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(0, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			return runtime.Frame{}
+		}
+	}
+}
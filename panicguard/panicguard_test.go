@@ -3,6 +3,8 @@ package panicguard
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,6 +32,95 @@ func TestPanic(t *testing.T) {
 			panic("test error")
 		})
 
-		assert.Equal(PanicOccured{"test error"}, err)
+		if assert.IsType(PanicOccured{}, err) {
+			assert.Equal("test error", err.(PanicOccured).Reason)
+		}
+		assert.EqualError(err, "panic occured: test error")
+	})
+
+	t.Run("stack and frame should be captured", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New()
+
+		err := g.Run(context.Background(), func(ctx context.Context) error {
+			var m map[string]int
+			m["a"] = 1
+			return nil
+		})
+
+		po, ok := err.(PanicOccured)
+		if !assert.True(ok) {
+			return
+		}
+		assert.Contains(string(po.Stack()), "TestPanic")
+		assert.True(strings.HasSuffix(po.Frame().File, "panicguard_test.go"), po.Frame().File)
+		assert.Contains(po.Frame().Function, "TestPanic")
+
+		assert.Equal(po.Error(), fmt.Sprintf("%v", po))
+		assert.Equal(po.Error(), fmt.Sprintf("%s", po))
+		verbose := fmt.Sprintf("%+v", po)
+		assert.True(strings.HasPrefix(verbose, po.Error()+"\n"))
+		assert.Contains(verbose, string(po.Stack()))
+	})
+}
+
+func TestPanicOptions(t *testing.T) {
+	t.Run("handler should be called with the panic", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var reported []PanicOccured
+		g := New(WithHandler(func(ctx context.Context, po PanicOccured) {
+			reported = append(reported, po)
+		}))
+
+		err := g.Run(context.Background(), func(ctx context.Context) error {
+			panic("test error")
+		})
+
+		assert.Error(err)
+		if assert.Len(reported, 1) {
+			assert.Equal("test error", reported[0].Reason)
+		}
+	})
+
+	t.Run("panic should be repanicked after the handler", func(t *testing.T) {
+		assert := assert.New(t)
+
+		handled := false
+		g := New(
+			WithHandler(func(ctx context.Context, po PanicOccured) {
+				handled = true
+			}),
+			WithRepanic(IsRuntimeError),
+		)
+
+		assert.PanicsWithError("assignment to entry in nil map", func() {
+			g.Run(context.Background(), func(ctx context.Context) error {
+				var m map[string]int
+				m["a"] = 1
+				return nil
+			})
+		})
+		assert.True(handled)
+
+		err := g.Run(context.Background(), func(ctx context.Context) error {
+			panic("test error")
+		})
+		assert.EqualError(err, "panic occured: test error")
+	})
+
+	t.Run("panic should be converted into the custom error", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(WithErrorFunc(func(po PanicOccured) error {
+			return fmt.Errorf("custom: %v", po.Reason)
+		}))
+
+		err := g.Run(context.Background(), func(ctx context.Context) error {
+			panic("test error")
+		})
+
+		assert.EqualError(err, "custom: test error")
 	})
 }
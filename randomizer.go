@@ -1,7 +1,53 @@
 package guard
 
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // Randomizer generates random numbers.
 type Randomizer interface {
 	// Float64 returns random floating point number in [0.0,1.0).
 	Float64() float64
 }
+
+// NewRandomizer creates a Randomizer that is safe for concurrent use.
+// Each Randomizer is seeded differently, so that the instances created
+// at the same time do not generate the same sequence.
+func NewRandomizer() Randomizer {
+	return NewSeededRandomizer(newSeed())
+}
+
+// NewSeededRandomizer creates a Randomizer that is safe for concurrent use
+// and generates the same sequence for the same seed.
+// It is useful to make the tests deterministic.
+func NewSeededRandomizer(seed int64) Randomizer {
+	return &lockedRandomizer{rnd: rand.New(rand.NewSource(seed))}
+}
+
+type lockedRandomizer struct {
+	rnd *rand.Rand
+	mu  sync.Mutex
+}
+
+func (r *lockedRandomizer) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Float64()
+}
+
+var seedCount int64
+
+// newSeed returns a seed from crypto/rand.
+// It falls back to the current time and a counter if crypto/rand fails.
+func newSeed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err == nil {
+		return int64(binary.LittleEndian.Uint64(b[:]))
+	}
+	return time.Now().UnixNano() + atomic.AddInt64(&seedCount, 1)
+}
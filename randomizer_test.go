@@ -0,0 +1,50 @@
+package guard
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomizer(t *testing.T) {
+	sequence := func(r Randomizer) []float64 {
+		s := make([]float64, 10)
+		for i := range s {
+			s[i] = r.Float64()
+		}
+		return s
+	}
+
+	t.Run("seeded randomizer should be deterministic", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(sequence(NewSeededRandomizer(1)), sequence(NewSeededRandomizer(1)))
+		assert.NotEqual(sequence(NewSeededRandomizer(1)), sequence(NewSeededRandomizer(2)))
+	})
+
+	t.Run("randomizers should be seeded differently", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.NotEqual(sequence(NewRandomizer()), sequence(NewRandomizer()))
+	})
+
+	t.Run("randomizer should be safe for concurrent use", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r := NewRandomizer()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					f := r.Float64()
+					assert.True(f >= 0 && f < 1)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
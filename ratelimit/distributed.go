@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// NewGCRA creates a Limiter that applies the generic cell rate algorithm
+// to the value of key in the store, so the rate limit is shared by
+// all processes using the same store and key.
+//
+// The limiter allows r executions per second on average
+// with bursts of up to burst executions.
+// The store keeps the theoretical arrival time of the next execution
+// as nanoseconds from the Unix time.
+func NewGCRA(store Store, key string, r float64, burst int, options ...LimiterOption) NLimiter {
+	o := newLimiterOptions(options)
+	return &gcra{
+		clock:    o.clock,
+		store:    store,
+		key:      key,
+		interval: durationFromTokens(1, r),
+		burst:    burst,
+	}
+}
+
+type gcra struct {
+	clock    guard.Clock
+	store    Store
+	key      string
+	interval time.Duration
+	burst    int
+}
+
+func (g *gcra) Wait(ctx context.Context) error {
+	return g.WaitN(ctx, 1)
+}
+
+func (g *gcra) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if n > g.burst {
+		return ErrExceedsBurst
+	}
+
+	increment := int64(g.interval) * int64(n)
+	tolerance := int64(g.interval) * int64(g.burst)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		old, err := g.store.Get(ctx, g.key)
+		if err != nil {
+			return err
+		}
+
+		now := g.clock.Now().UnixNano()
+		tat := old
+		if tat < now {
+			tat = now
+		}
+		newTAT := tat + increment
+
+		if allowAt := newTAT - tolerance; allowAt > now {
+			if err := sleep(ctx, g.clock, time.Duration(allowAt-now)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ok, err := g.store.CompareAndSwap(ctx, g.key, old, newTAT, time.Duration(newTAT-now))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+}
+
+// NewDistributedFixedWindow creates a Limiter that allows up to limit executions
+// in each window of the given size, counting the executions by incrementing
+// the value of the key in the store.
+// The key of each window is the given key suffixed by the index of the window.
+func NewDistributedFixedWindow(store Store, key string, limit int, size time.Duration, options ...LimiterOption) NLimiter {
+	o := newLimiterOptions(options)
+	return &distributedFixedWindow{
+		clock: o.clock,
+		store: store,
+		key:   key,
+		limit: limit,
+		size:  size,
+	}
+}
+
+type distributedFixedWindow struct {
+	clock guard.Clock
+	store Store
+	key   string
+	limit int
+	size  time.Duration
+}
+
+func (w *distributedFixedWindow) Wait(ctx context.Context) error {
+	return w.WaitN(ctx, 1)
+}
+
+func (w *distributedFixedWindow) WaitN(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if n > w.limit {
+		return ErrExceedsBurst
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		now := w.clock.Now()
+		idx := now.UnixNano() / int64(w.size)
+		next := time.Unix(0, (idx+1)*int64(w.size))
+
+		count, err := w.store.Increment(ctx, w.key+":"+strconv.FormatInt(idx, 10), int64(n), next.Sub(now))
+		if err != nil {
+			return err
+		}
+		if count <= int64(w.limit) {
+			return nil
+		}
+
+		if err := sleep(ctx, w.clock, next.Sub(now)); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep sleeps for d or until the context is done.
+// It returns context.DeadlineExceeded immediately when the deadline of the
+// context is earlier than d.
+func sleep(ctx context.Context, clock guard.Clock, d time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(clock.Now().Add(d)) {
+		return context.DeadlineExceeded
+	}
+
+	t := clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("values should expire after ttl", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		s := NewMemoryStore(WithClock(clock))
+		ctx := context.Background()
+
+		v, _ := s.Increment(ctx, "key", 1, time.Second)
+		assert.Equal(int64(1), v)
+		clock.Add(500 * time.Millisecond)
+		v, _ = s.Increment(ctx, "key", 1, time.Second)
+		assert.Equal(int64(2), v)
+
+		clock.Add(500 * time.Millisecond)
+		v, _ = s.Get(ctx, "key")
+		assert.Equal(int64(0), v)
+	})
+
+	t.Run("compare and swap should regard a missing key as 0", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := NewMemoryStore()
+		ctx := context.Background()
+
+		ok, _ := s.CompareAndSwap(ctx, "key", 1, 2, time.Second)
+		assert.False(ok)
+		ok, _ = s.CompareAndSwap(ctx, "key", 0, 2, time.Second)
+		assert.True(ok)
+
+		v, _ := s.Get(ctx, "key")
+		assert.Equal(int64(2), v)
+	})
+}
+
+func TestGCRA(t *testing.T) {
+	t.Run("executions should be limited by rate and burst", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		s := NewMemoryStore(WithClock(clock))
+		l := NewGCRA(s, "key", 1, 2, WithClock(clock))
+
+		assert.NoError(l.Wait(context.Background()))
+		assert.NoError(l.Wait(context.Background()))
+
+		done := make(chan error)
+		go func() {
+			done <- l.Wait(context.Background())
+		}()
+		clock.BlockUntil(1)
+		select {
+		case <-done:
+			assert.Fail("wait should be blocked")
+		default:
+		}
+
+		clock.Add(time.Second)
+		assert.NoError(<-done)
+	})
+
+	t.Run("limiters should share the store", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := NewMemoryStore()
+		l1 := NewGCRA(s, "key", 0.001, 1)
+		l2 := NewGCRA(s, "key", 0.001, 1)
+
+		assert.NoError(l1.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.Equal(context.DeadlineExceeded, l2.Wait(ctx))
+	})
+}
+
+func TestDistributedFixedWindow(t *testing.T) {
+	t.Run("executions should be limited in each window", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		s := NewMemoryStore(WithClock(clock))
+		l1 := NewDistributedFixedWindow(s, "key", 2, time.Second, WithClock(clock))
+		l2 := NewDistributedFixedWindow(s, "key", 2, time.Second, WithClock(clock))
+
+		assert.NoError(l1.Wait(context.Background()))
+		assert.NoError(l2.Wait(context.Background()))
+
+		done := make(chan error)
+		go func() {
+			done <- l1.Wait(context.Background())
+		}()
+		clock.BlockUntil(1)
+
+		clock.Add(time.Second)
+		assert.NoError(<-done)
+	})
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// ErrRateLimited is a error that is returned when the process is rejected
+// by the rate limit without waiting.
+type ErrRateLimited struct {
+	// RetryAfter is the duration until the process becomes executable.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e ErrRateLimited) Error() string {
+	return fmt.Sprintf("rate limited: retry after %v", e.RetryAfter)
+}
+
+// ErrCostNotSupported is a error that is returned when the process costs more than 1
+// but the reserver does not implement NReserver.
+var ErrCostNotSupported = errors.New("cost is not supported by the reserver")
+
+// NewFailFast creates a new guard.Guard with capability of rate limit
+// that does not queue the process.
+// When the process is not executable immediately, ErrRateLimited is returned.
+// When the process can never be executed, ErrExceedsBurst is returned.
+//
+// The process consumes the number of units given by the cost function,
+// which is CostFromContext by default.
+// When the cost is not 1, the reserver is expected to implement NReserver.
+// Otherwise ErrCostNotSupported is returned.
+// The process that costs 0 or less is not limited.
+func NewFailFast(reserver Reserver, options ...FailFastOption) guard.Guard {
+	ff := &failFast{
+		cost: CostFromContext,
+	}
+	for _, o := range options {
+		o(ff)
+	}
+
+	return guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
+		n := ff.cost(ctx)
+		if n <= 0 {
+			return f(ctx)
+		}
+
+		r := reserveN(reserver, n)
+		if r == nil {
+			return ErrCostNotSupported
+		}
+		if !r.OK() {
+			// the process can never be executed, so there is no time to retry after.
+			return ErrExceedsBurst
+		}
+
+		if delay := r.Delay(); delay > ff.maxDelay {
+			r.Cancel()
+			return ErrRateLimited{delay}
+		}
+		if err := r.Wait(ctx); err != nil {
+			return err
+		}
+
+		return f(ctx)
+	})
+}
+
+type failFast struct {
+	maxDelay time.Duration
+	cost     func(context.Context) int
+}
+
+// FailFastOption is the optional parameter for NewFailFast.
+type FailFastOption func(*failFast)
+
+// WithMaxDelay set the maximum duration to wait for the process to become executable.
+// ErrRateLimited is returned only when the process must wait longer than d.
+// The default is 0.
+func WithMaxDelay(d time.Duration) FailFastOption {
+	return FailFastOption(func(ff *failFast) {
+		ff.maxDelay = d
+	})
+}
+
+// WithFailFastCostFunc set the function that computes the cost of the process.
+// It is the same as WithCostFunc but for NewFailFast.
+func WithFailFastCostFunc(f func(ctx context.Context) int) FailFastOption {
+	return FailFastOption(func(ff *failFast) {
+		ff.cost = f
+	})
+}
+
+// reserveN reserves n units, which must be positive.
+func reserveN(reserver Reserver, n int) Reservation {
+	if n == 1 {
+		return reserver.Reserve()
+	}
+	if r, ok := reserver.(NReserver); ok {
+		return r.ReserveN(n)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailFast(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewFailFast(NewTokenBucket(1, 1))
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("process should be rejected without waiting", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 1, WithClock(clock))
+		g := NewFailFast(tb)
+
+		count := 0
+		f := func(_ context.Context) error {
+			count++
+			return nil
+		}
+
+		assert.NoError(g.Run(context.Background(), f))
+		assert.Equal(ErrRateLimited{time.Second}, g.Run(context.Background(), f))
+		assert.Equal(1, count)
+		assert.Equal(float64(0), tb.Tokens())
+
+		clock.Add(500 * time.Millisecond)
+		assert.Equal(ErrRateLimited{500 * time.Millisecond}, g.Run(context.Background(), f))
+
+		clock.Add(500 * time.Millisecond)
+		assert.NoError(g.Run(context.Background(), f))
+		assert.Equal(2, count)
+	})
+
+	t.Run("process should wait up to max delay", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 1, WithClock(clock))
+		g := NewFailFast(tb, WithMaxDelay(time.Second))
+
+		f := func(_ context.Context) error {
+			return nil
+		}
+		assert.NoError(g.Run(context.Background(), f))
+
+		done := make(chan error)
+		go func() {
+			done <- g.Run(context.Background(), f)
+		}()
+		clock.BlockUntil(1)
+
+		assert.Equal(ErrRateLimited{2 * time.Second}, g.Run(context.Background(), f))
+
+		clock.Add(time.Second)
+		assert.NoError(<-done)
+	})
+
+	t.Run("rejected error should be formatted", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.EqualError(ErrRateLimited{time.Second}, "rate limited: retry after 1s")
+	})
+
+	t.Run("cost of 0 or less should not be limited nor refill the limiter", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewFailFast(NewFixedWindow(1, time.Hour, WithClock(clock)))
+
+		f := func(_ context.Context) error {
+			return nil
+		}
+		assert.NoError(g.Run(WithCost(context.Background(), -10), f))
+		assert.NoError(g.Run(WithCost(context.Background(), 0), f))
+
+		passed := 0
+		for i := 0; i < 20; i++ {
+			if g.Run(context.Background(), f) == nil {
+				passed++
+			}
+		}
+		assert.Equal(1, passed)
+	})
+
+	t.Run("cost should be computed by the cost func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewFailFast(NewTokenBucket(1, 3, WithClock(clock)), WithFailFastCostFunc(func(_ context.Context) int {
+			return 2
+		}))
+
+		f := func(_ context.Context) error { return nil }
+		assert.NoError(g.Run(context.Background(), f))
+		assert.Equal(ErrRateLimited{time.Second}, g.Run(context.Background(), f))
+	})
+
+	t.Run("cost should be rejected without NReserver", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewFailFast(struct{ Reserver }{NewTokenBucket(1, 3)})
+
+		f := func(_ context.Context) error {
+			return nil
+		}
+		assert.Equal(ErrCostNotSupported, g.Run(WithCost(context.Background(), 2), f))
+		assert.NoError(g.Run(context.Background(), f))
+	})
+
+	t.Run("process that can never be executed should not be told to retry", func(t *testing.T) {
+		assert := assert.New(t)
+
+		f := func(_ context.Context) error {
+			return nil
+		}
+
+		g := NewFailFast(NewTokenBucket(1, 1))
+		assert.Equal(ErrExceedsBurst, g.Run(WithCost(context.Background(), 2), f))
+
+		g = NewFailFast(NewTokenBucket(0, 1))
+		assert.NoError(g.Run(context.Background(), f))
+		assert.Equal(ErrExceedsBurst, g.Run(context.Background(), f))
+	})
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"github.com/morikuni/guard"
+)
+
+type limiterOptions struct {
+	clock      guard.Clock
+	randomizer guard.Randomizer
+	accept     func(error) bool
+}
+
+// LimiterOption is the optional parameter for the limiters in this package.
+type LimiterOption func(*limiterOptions)
+
+// WithClock set the clock used by the limiter.
+// The default is guard.SystemClock.
+func WithClock(c guard.Clock) LimiterOption {
+	return LimiterOption(func(o *limiterOptions) {
+		o.clock = c
+	})
+}
+
+// WithRandomizer set the randomizer used by the limiter.
+// The default is guard.NewRandomizer().
+func WithRandomizer(r guard.Randomizer) LimiterOption {
+	return LimiterOption(func(o *limiterOptions) {
+		o.randomizer = r
+	})
+}
+
+// WithAcceptFunc set the function that reports whether the error returned by
+// the process means that the backend accepted it, e.g. the error of a client-side validation.
+// It is used by NewAdaptiveThrottle.
+// The default accepts only nil.
+func WithAcceptFunc(f func(err error) bool) LimiterOption {
+	return LimiterOption(func(o *limiterOptions) {
+		o.accept = f
+	})
+}
+
+func newLimiterOptions(options []LimiterOption) limiterOptions {
+	o := limiterOptions{
+		clock:      guard.SystemClock,
+		randomizer: guard.NewRandomizer(),
+		accept:     func(err error) bool { return err == nil },
+	}
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
@@ -14,13 +14,103 @@ type Limiter interface {
 	Wait(ctx context.Context) error
 }
 
+// NLimiter is a Limiter that can consume multiple units at once.
+type NLimiter interface {
+	Limiter
+
+	// WaitN sleeps until the process that costs n units becomes executable
+	// according to the state of rate limit.
+	WaitN(ctx context.Context, n int) error
+}
+
+// Reserver is a Limiter that can reserve the execution in advance.
+type Reserver interface {
+	Limiter
+
+	// Reserve reserves a token and returns the Reservation that tells
+	// how long the caller must wait before the execution.
+	Reserve() Reservation
+}
+
+// NReserver is a Reserver that can reserve multiple units at once.
+type NReserver interface {
+	Reserver
+	NLimiter
+
+	// ReserveN reserves n tokens and returns the Reservation that tells
+	// how long the caller must wait before the execution.
+	ReserveN(n int) Reservation
+}
+
+type costKey struct{}
+
+// WithCost returns a new context that holds the cost of the process.
+// The cost is the number of units the process consumes from the limiter.
+func WithCost(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, costKey{}, n)
+}
+
+// CostFromContext returns the cost held by the context.
+// It returns 1 if the context does not have the cost.
+func CostFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(costKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
 // New creates a new guard.Guard with capability of rate limit.
-func New(limitter Limiter) guard.Guard {
+//
+// The process consumes the number of units given by the cost function,
+// which is CostFromContext by default.
+// When the cost is not 1, the limiter is expected to implement NLimiter.
+// Otherwise Wait is called as many times as the cost.
+func New(limitter Limiter, options ...Option) guard.Guard {
+	rl := &rateLimit{
+		cost: CostFromContext,
+	}
+	for _, o := range options {
+		o(rl)
+	}
+
 	return guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
-		if err := limitter.Wait(ctx); err != nil {
+		if err := waitN(ctx, limitter, rl.cost(ctx)); err != nil {
 			return err
 		}
 
 		return f(ctx)
 	})
 }
+
+type rateLimit struct {
+	cost func(context.Context) int
+}
+
+// Option is the optional parameter for New.
+type Option func(*rateLimit)
+
+// WithCostFunc set the function that computes the cost of the process.
+func WithCostFunc(f func(ctx context.Context) int) Option {
+	return Option(func(rl *rateLimit) {
+		rl.cost = f
+	})
+}
+
+func waitN(ctx context.Context, limiter Limiter, n int) error {
+	switch {
+	case n == 1:
+		return limiter.Wait(ctx)
+	case n <= 0:
+		return nil
+	}
+
+	if l, ok := limiter.(NLimiter); ok {
+		return l.WaitN(ctx, n)
+	}
+	for i := 0; i < n; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
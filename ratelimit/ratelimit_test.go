@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLimiter struct {
+	Waits int
+}
+
+func (l *testLimiter) Wait(ctx context.Context) error {
+	l.Waits++
+	return nil
+}
+
+type testNLimiter struct {
+	testLimiter
+	N []int
+}
+
+func (l *testNLimiter) WaitN(ctx context.Context, n int) error {
+	l.N = append(l.N, n)
+	return nil
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(&testLimiter{})
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("cost should be taken from the context", func(t *testing.T) {
+		assert := assert.New(t)
+
+		l := &testNLimiter{}
+		g := New(l)
+
+		f := func(_ context.Context) error { return nil }
+		g.Run(context.Background(), f)
+		g.Run(WithCost(context.Background(), 5), f)
+		g.Run(WithCost(context.Background(), 0), f)
+
+		assert.Equal(1, l.Waits)
+		assert.Equal([]int{5}, l.N)
+	})
+
+	t.Run("cost should be computed by the function", func(t *testing.T) {
+		assert := assert.New(t)
+
+		l := &testNLimiter{}
+		g := New(l, WithCostFunc(func(ctx context.Context) int {
+			return ctx.Value("size").(int) / 10
+		}))
+
+		g.Run(context.WithValue(context.Background(), "size", 30), func(_ context.Context) error { return nil })
+
+		assert.Equal([]int{3}, l.N)
+	})
+
+	t.Run("wait should be called for each unit without WaitN", func(t *testing.T) {
+		assert := assert.New(t)
+
+		l := &testLimiter{}
+		g := New(l)
+
+		g.Run(WithCost(context.Background(), 3), func(_ context.Context) error { return nil })
+
+		assert.Equal(3, l.Waits)
+	})
+
+	t.Run("cost should be honoured by the built-in limiters", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 10, WithClock(clock))
+		g := New(tb)
+
+		err := g.Run(WithCost(context.Background(), 4), func(_ context.Context) error { return nil })
+		assert.NoError(err)
+		assert.Equal(float64(6), tb.Tokens())
+
+		assert.Equal(ErrExceedsBurst, New(tb).Run(WithCost(context.Background(), 11), func(_ context.Context) error { return nil }))
+
+		ff := NewFailFast(tb)
+		assert.Equal(ErrRateLimited{time.Second}, ff.Run(WithCost(context.Background(), 7), func(_ context.Context) error { return nil }))
+		assert.NoError(ff.Run(WithCost(context.Background(), 6), func(_ context.Context) error { return nil }))
+		assert.Equal(float64(0), tb.Tokens())
+	})
+}
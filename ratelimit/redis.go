@@ -0,0 +1,312 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisStore is a Store backed by a server speaking the Redis protocol.
+type RedisStore interface {
+	Store
+
+	// Close closes all connections to the server.
+	Close() error
+}
+
+// NewRedisStore creates a RedisStore connecting to the server at addr.
+// The server must support EVAL with Lua scripts to update values atomically.
+func NewRedisStore(addr string, options ...RedisStoreOption) RedisStore {
+	s := &redisStore{
+		addr:     addr,
+		poolSize: 10,
+	}
+	for _, o := range options {
+		o(s)
+	}
+	s.pool = make(chan *redisConn, s.poolSize)
+	return s
+}
+
+// RedisStoreOption is the optional parameter for NewRedisStore.
+type RedisStoreOption func(*redisStore)
+
+// WithPassword set the password to authenticate the connections.
+func WithPassword(password string) RedisStoreOption {
+	return RedisStoreOption(func(s *redisStore) {
+		s.password = password
+	})
+}
+
+// WithPoolSize set the maximum number of idle connections kept by RedisStore.
+// The default is 10.
+func WithPoolSize(n int) RedisStoreOption {
+	return RedisStoreOption(func(s *redisStore) {
+		s.poolSize = n
+	})
+}
+
+const (
+	incrementScript = `local v = redis.call('INCRBY', KEYS[1], ARGV[1])
+if v == tonumber(ARGV[1]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return v`
+
+	// compare values as strings, since Lua numbers cannot hold int64 precisely.
+	compareAndSwapScript = `if (redis.call('GET', KEYS[1]) or '0') == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0`
+)
+
+type redisStore struct {
+	addr     string
+	password string
+	poolSize int
+	pool     chan *redisConn
+}
+
+func (s *redisStore) Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	reply, err := s.do(ctx, "EVAL", incrementScript, "1", key, strconv.FormatInt(n, 10), formatMilliseconds(ttl))
+	if err != nil {
+		return 0, err
+	}
+	return redisInt(reply)
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (int64, error) {
+	reply, err := s.do(ctx, "GET", key)
+	if err != nil {
+		return 0, err
+	}
+	switch r := reply.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return strconv.ParseInt(r, 10, 64)
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+}
+
+func (s *redisStore) CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error) {
+	reply, err := s.do(ctx, "EVAL", compareAndSwapScript, "1", key, strconv.FormatInt(old, 10), strconv.FormatInt(new, 10), formatMilliseconds(ttl))
+	if err != nil {
+		return false, err
+	}
+	n, err := redisInt(reply)
+	return n == 1, err
+}
+
+func (s *redisStore) Close() error {
+	for {
+		select {
+		case c := <-s.pool:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *redisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, pooled, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, read, err := c.doContext(ctx, args...)
+	if err != nil && !read && pooled && !isTimeout(err) && ctx.Err() == nil {
+		// the idle connection may have been closed by the server.
+		c.Close()
+		if c, err = s.dial(ctx); err != nil {
+			return nil, err
+		}
+		reply, _, err = c.doContext(ctx, args...)
+	}
+	if _, ok := err.(redisError); err != nil && !ok {
+		// the connection may be broken.
+		c.Close()
+		return nil, err
+	}
+	s.put(c)
+	return reply, err
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
+
+// get returns an idle connection in the pool, or a new connection.
+// It reports whether the connection is taken from the pool.
+func (s *redisStore) get(ctx context.Context) (*redisConn, bool, error) {
+	select {
+	case c := <-s.pool:
+		return c, true, nil
+	default:
+	}
+	c, err := s.dial(ctx)
+	return c, false, err
+}
+
+func (s *redisStore) dial(ctx context.Context) (*redisConn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn, bufio.NewReader(conn), bufio.NewWriter(conn)}
+	if s.password != "" {
+		if _, _, err := c.doContext(ctx, "AUTH", s.password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *redisStore) put(c *redisConn) {
+	select {
+	case s.pool <- c:
+	default:
+		c.Close()
+	}
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// doContext is the same as do, but the I/O is bounded by the deadline of ctx
+// and interrupted when ctx is done.
+// The connection must be closed if ctx.Err() is returned,
+// because the reply may be left unread on the connection.
+func (c *redisConn) doContext(ctx context.Context, args ...string) (interface{}, bool, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.SetDeadline(deadline)
+	} else {
+		c.SetDeadline(time.Time{})
+	}
+
+	// interrupt the blocking I/O when the context is done without a deadline,
+	// e.g. cancelled.
+	stop := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.SetDeadline(time.Unix(1, 0))
+			interrupted <- true
+		case <-stop:
+			interrupted <- false
+		}
+	}()
+
+	reply, read, err := c.do(args...)
+	close(stop)
+	if <-interrupted {
+		return nil, read, ctx.Err()
+	}
+	return reply, read, err
+}
+
+// do sends the command and reads the reply.
+// It reports whether any part of the reply is read.
+func (c *redisConn) do(args ...string) (interface{}, bool, error) {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, false, err
+	}
+	if _, err := c.r.Peek(1); err != nil {
+		return nil, false, err
+	}
+	reply, err := readRedisReply(c.r)
+	return reply, true, err
+}
+
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readRedisReply reads a reply of the Redis protocol.
+// The reply is one of string, int64, []interface{}, nil or redisError.
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		replies := make([]interface{}, n)
+		for i := range replies {
+			if replies[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return replies, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+	}
+}
+
+func redisInt(reply interface{}) (int64, error) {
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	return n, nil
+}
+
+func formatMilliseconds(d time.Duration) string {
+	ms := int64((d + time.Millisecond - 1) / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
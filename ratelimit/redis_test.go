@@ -0,0 +1,309 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRedisServer is a stand-in of Redis server that understands
+// the commands used by RedisStore, and keeps values in a memory store.
+type testRedisServer struct {
+	listener net.Listener
+	store    Store
+	password string
+	wg       sync.WaitGroup
+}
+
+func newTestRedisServer(t *testing.T, password string) *testRedisServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testRedisServer{
+		listener: l,
+		store:    NewMemoryStore(),
+		password: password,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+func (s *testRedisServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testRedisServer) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *testRedisServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testRedisServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authorized := s.password == ""
+	for {
+		req, err := readRedisReply(r)
+		if err != nil {
+			return
+		}
+		args := make([]string, 0)
+		for _, a := range req.([]interface{}) {
+			args = append(args, a.(string))
+		}
+
+		if args[0] == "AUTH" {
+			if args[1] != s.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authorized = true
+			fmt.Fprint(conn, "+OK\r\n")
+			continue
+		}
+		if !authorized {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+
+		ctx := context.Background()
+		switch {
+		case args[0] == "GET":
+			v, _ := s.store.Get(ctx, args[1])
+			if v == 0 {
+				fmt.Fprint(conn, "$-1\r\n")
+				continue
+			}
+			sv := strconv.FormatInt(v, 10)
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(sv), sv)
+		case args[0] == "EVAL" && args[1] == incrementScript:
+			n, _ := strconv.ParseInt(args[4], 10, 64)
+			ttl, _ := strconv.ParseInt(args[5], 10, 64)
+			v, _ := s.store.Increment(ctx, args[3], n, time.Duration(ttl)*time.Millisecond)
+			fmt.Fprintf(conn, ":%d\r\n", v)
+		case args[0] == "EVAL" && args[1] == compareAndSwapScript:
+			old, _ := strconv.ParseInt(args[4], 10, 64)
+			new, _ := strconv.ParseInt(args[5], 10, 64)
+			ttl, _ := strconv.ParseInt(args[6], 10, 64)
+			ok, _ := s.store.CompareAndSwap(ctx, args[3], old, new, time.Duration(ttl)*time.Millisecond)
+			if ok {
+				fmt.Fprint(conn, ":1\r\n")
+			} else {
+				fmt.Fprint(conn, ":0\r\n")
+			}
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Run("values should be updated on the server", func(t *testing.T) {
+		assert := assert.New(t)
+
+		server := newTestRedisServer(t, "")
+		defer server.Close()
+
+		s := NewRedisStore(server.Addr())
+		defer s.Close()
+		ctx := context.Background()
+
+		v, err := s.Get(ctx, "key")
+		assert.NoError(err)
+		assert.Equal(int64(0), v)
+
+		v, err = s.Increment(ctx, "key", 3, time.Minute)
+		assert.NoError(err)
+		assert.Equal(int64(3), v)
+
+		ok, err := s.CompareAndSwap(ctx, "key", 1, 10, time.Minute)
+		assert.NoError(err)
+		assert.False(ok)
+
+		ok, err = s.CompareAndSwap(ctx, "key", 3, 10, time.Minute)
+		assert.NoError(err)
+		assert.True(ok)
+
+		v, err = s.Get(ctx, "key")
+		assert.NoError(err)
+		assert.Equal(int64(10), v)
+	})
+
+	t.Run("connection should be authenticated with password", func(t *testing.T) {
+		assert := assert.New(t)
+
+		server := newTestRedisServer(t, "secret")
+		defer server.Close()
+		ctx := context.Background()
+
+		s := NewRedisStore(server.Addr())
+		_, err := s.Get(ctx, "key")
+		assert.EqualError(err, "redis: NOAUTH Authentication required.")
+		s.Close()
+
+		s = NewRedisStore(server.Addr(), WithPassword("secret"), WithPoolSize(1))
+		_, err = s.Get(ctx, "key")
+		assert.NoError(err)
+		s.Close()
+	})
+
+	t.Run("rate limit should be shared through the server", func(t *testing.T) {
+		assert := assert.New(t)
+
+		server := newTestRedisServer(t, "")
+		defer server.Close()
+
+		s := NewRedisStore(server.Addr())
+		defer s.Close()
+
+		var count int32
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			l := NewGCRA(s, "gcra", 0.001, 5)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+					err := l.Wait(ctx)
+					cancel()
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					count++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(int32(5), count)
+	})
+
+	t.Run("error should be returned when the server is unavailable", func(t *testing.T) {
+		assert := assert.New(t)
+
+		server := newTestRedisServer(t, "")
+		server.Close()
+
+		s := NewRedisStore(server.Addr())
+		defer s.Close()
+
+		_, err := s.Get(context.Background(), "key")
+		assert.Error(err)
+	})
+
+	t.Run("process should be interrupted when the context is cancelled", func(t *testing.T) {
+		assert := assert.New(t)
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		go func() {
+			// accept the connection but never reply.
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			ioutil.ReadAll(conn)
+		}()
+
+		s := NewRedisStore(l.Addr().String())
+		defer s.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err = s.Get(ctx, "key")
+		assert.Equal(context.Canceled, err)
+	})
+
+	t.Run("authentication should be interrupted when the context is done", func(t *testing.T) {
+		assert := assert.New(t)
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		go func() {
+			// accept the connection but never reply.
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			ioutil.ReadAll(conn)
+		}()
+
+		s := NewRedisStore(l.Addr().String(), WithPassword("password"))
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err = s.Get(ctx, "key")
+		assert.Error(err)
+		assert.True(time.Since(start) < time.Second)
+	})
+
+	t.Run("idle connection closed by the server should be replaced", func(t *testing.T) {
+		assert := assert.New(t)
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		go func() {
+			// reply to only one command on each connection.
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				if _, err := readRedisReply(bufio.NewReader(conn)); err == nil {
+					fmt.Fprint(conn, ":1\r\n")
+				}
+				conn.Close()
+			}
+		}()
+
+		s := NewRedisStore(l.Addr().String())
+		defer s.Close()
+
+		v, err := s.Increment(context.Background(), "key", 1, time.Second)
+		assert.NoError(err)
+		assert.Equal(int64(1), v)
+
+		v, err = s.Increment(context.Background(), "key", 1, time.Second)
+		assert.NoError(err)
+		assert.Equal(int64(1), v)
+	})
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// Reservation holds the execution reserved by Reserver.
+type Reservation interface {
+	// OK returns whether the reservation succeeded.
+	OK() bool
+
+	// Delay returns the duration until the reserved execution becomes available.
+	Delay() time.Duration
+
+	// Cancel returns the reservation to the limiter if it has not been used yet.
+	Cancel()
+
+	// Wait sleeps until the reserved execution becomes available.
+	// The reservation is cancelled when the context is done before that,
+	// or when the deadline of the context is earlier than that.
+	Wait(ctx context.Context) error
+}
+
+type reservation struct {
+	clock     guard.Clock
+	ok        bool
+	timeToAct time.Time
+	cancel    func(now time.Time)
+
+	cancelled bool
+	mu        sync.Mutex
+}
+
+func (r *reservation) OK() bool {
+	return r.ok
+}
+
+func (r *reservation) Delay() time.Duration {
+	if !r.ok {
+		return time.Duration(math.MaxInt64)
+	}
+	d := r.timeToAct.Sub(r.clock.Now())
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func (r *reservation) Wait(ctx context.Context) error {
+	if !r.ok {
+		return ErrExceedsBurst
+	}
+
+	delay := r.Delay()
+	if delay <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.timeToAct) {
+		r.Cancel()
+		return context.DeadlineExceeded
+	}
+
+	t := r.clock.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+func (r *reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if r.cancelled || !now.Before(r.timeToAct) {
+		return
+	}
+	r.cancelled = true
+	r.cancel(now)
+}
+
+// freeReservation returns the reservation for the process that costs nothing.
+func freeReservation(clock guard.Clock, now time.Time) *reservation {
+	return &reservation{
+		clock:     clock,
+		ok:        true,
+		timeToAct: now,
+		cancel:    func(_ time.Time) {},
+	}
+}
+
+// wait reserves the execution and sleeps until it becomes available.
+func wait(ctx context.Context, reserve func() *reservation) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	return reserve().Wait(ctx)
+}
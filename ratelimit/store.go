@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// Store is a storage of integers shared by the processes
+// to apply a rate limit across them.
+type Store interface {
+	// Increment atomically adds n to the value of key and returns the new value.
+	// When the key is created by the increment, it expires after ttl.
+	Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+
+	// Get returns the value of key. It returns 0 if the key does not exist.
+	Get(ctx context.Context, key string) (int64, error)
+
+	// CompareAndSwap atomically sets new to the value of key only if the
+	// current value is old, and makes the key expire after ttl.
+	// The key that does not exist is regarded as 0.
+	CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error)
+}
+
+// NewMemoryStore creates a Store that keeps values in memory.
+// It is useful to test or to run a single process.
+func NewMemoryStore(options ...LimiterOption) Store {
+	o := newLimiterOptions(options)
+	return &memoryStore{
+		clock:  o.clock,
+		values: make(map[string]memoryValue),
+	}
+}
+
+type memoryStore struct {
+	clock  guard.Clock
+	values map[string]memoryValue
+	mu     sync.Mutex
+}
+
+type memoryValue struct {
+	value    int64
+	expireAt time.Time
+}
+
+func (s *memoryStore) Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	v, ok := s.get(now, key)
+	if !ok {
+		v.expireAt = now.Add(ttl)
+	}
+	v.value += n
+	s.values[key] = v
+	return v.value, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, _ := s.get(s.clock.Now(), key)
+	return v.value, nil
+}
+
+func (s *memoryStore) CompareAndSwap(ctx context.Context, key string, old, new int64, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	v, _ := s.get(now, key)
+	if v.value != old {
+		return false, nil
+	}
+	s.values[key] = memoryValue{new, now.Add(ttl)}
+	return true, nil
+}
+
+// get returns the value of key that has not expired.
+// s.mu must be held.
+func (s *memoryStore) get(now time.Time, key string) (memoryValue, bool) {
+	v, ok := s.values[key]
+	if !ok {
+		return memoryValue{}, false
+	}
+	if !now.Before(v.expireAt) {
+		delete(s.values, key)
+		return memoryValue{}, false
+	}
+	return v, true
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// ErrThrottled is a error that is returned when the process is rejected
+// by the adaptive throttle without being executed.
+var ErrThrottled = errors.New("throttled")
+
+// Throttle is a guard.Guard with client-side adaptive throttling.
+type Throttle interface {
+	guard.Guard
+
+	// RejectProbability returns the current probability to reject the process.
+	RejectProbability() float64
+}
+
+// throttleBuckets is the number of buckets the window is divided into.
+const throttleBuckets = 10
+
+// NewAdaptiveThrottle creates a new Throttle that rejects the process locally
+// with the probability
+//
+//  max(0, (Requests - K * Accepts) / (Requests + 1))
+//
+// where Requests is the number of processes including the rejected ones, and
+// Accepts is the number of processes accepted by the backend, in the last window.
+//
+// The process that returns context.Canceled is not counted.
+// Whether the other processes are accepted is decided by the function set by
+// WithAcceptFunc, which accepts only nil by default.
+// K is usually 2. The smaller K rejects more aggressively.
+func NewAdaptiveThrottle(k float64, window time.Duration, options ...LimiterOption) Throttle {
+	o := newLimiterOptions(options)
+	width := window / throttleBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &throttle{
+		clock:      o.clock,
+		randomizer: o.randomizer,
+		accept:     o.accept,
+		k:          k,
+		width:      width,
+	}
+}
+
+type throttle struct {
+	clock      guard.Clock
+	randomizer guard.Randomizer
+	accept     func(error) bool
+	k          float64
+	width      time.Duration
+
+	buckets [throttleBuckets]throttleBucket
+	mu      sync.Mutex
+}
+
+type throttleBucket struct {
+	idx      int64
+	requests int
+	accepts  int
+}
+
+func (t *throttle) Run(ctx context.Context, f func(context.Context) error) error {
+	p := t.RejectProbability()
+	if p > 0 && t.randomizer.Float64() < p {
+		t.put(1, 0)
+		return ErrThrottled
+	}
+
+	err := f(ctx)
+	switch {
+	case err == context.Canceled:
+		// this is normal, so do nothing.
+	case t.accept(err):
+		t.put(1, 1)
+	default:
+		t.put(1, 0)
+	}
+	return err
+}
+
+func (t *throttle) RejectProbability() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := t.index(t.clock.Now())
+	var requests, accepts int
+	for _, b := range t.buckets {
+		if current-b.idx < throttleBuckets {
+			requests += b.requests
+			accepts += b.accepts
+		}
+	}
+	return math.Max(0, (float64(requests)-t.k*float64(accepts))/float64(requests+1))
+}
+
+func (t *throttle) put(requests, accepts int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	idx := t.index(t.clock.Now())
+	b := &t.buckets[idx%throttleBuckets]
+	if b.idx != idx {
+		*b = throttleBucket{idx: idx}
+	}
+	b.requests += requests
+	b.accepts += accepts
+}
+
+func (t *throttle) index(now time.Time) int64 {
+	return now.UnixNano() / int64(t.width)
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRandomizer float64
+
+func (r testRandomizer) Float64() float64 {
+	return float64(r)
+}
+
+func TestAdaptiveThrottle(t *testing.T) {
+	fail := func(_ context.Context) error {
+		return errors.New("test error")
+	}
+	succeed := func(_ context.Context) error {
+		return nil
+	}
+
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewAdaptiveThrottle(2, time.Minute)
+
+		assert.EqualError(g.Run(context.Background(), fail), "test error")
+	})
+
+	t.Run("reject probability should follow accepts", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewAdaptiveThrottle(2, time.Minute, WithClock(clock), WithRandomizer(testRandomizer(0.99)))
+
+		for i := 0; i < 5; i++ {
+			g.Run(context.Background(), succeed)
+		}
+		for i := 0; i < 14; i++ {
+			g.Run(context.Background(), fail)
+		}
+		// (19 - 2 * 5) / (19 + 1)
+		assert.InDelta(0.45, g.RejectProbability(), 1e-9)
+
+		g.Run(context.Background(), func(_ context.Context) error {
+			return context.Canceled
+		})
+		assert.InDelta(0.45, g.RejectProbability(), 1e-9)
+	})
+
+	t.Run("process should be rejected locally", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewAdaptiveThrottle(1, time.Minute, WithClock(clock), WithRandomizer(testRandomizer(0.1)))
+
+		for i := 0; i < 9; i++ {
+			g.Run(context.Background(), fail)
+		}
+		// 9 / 10
+		assert.InDelta(0.9, g.RejectProbability(), 1e-9)
+
+		count := 0
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			count++
+			return nil
+		})
+		assert.Equal(ErrThrottled, err)
+		assert.Equal(0, count)
+	})
+
+	t.Run("old requests should be forgotten", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewAdaptiveThrottle(2, time.Minute, WithClock(clock))
+
+		for i := 0; i < 9; i++ {
+			g.Run(context.Background(), fail)
+		}
+		clock.Add(30 * time.Second)
+		assert.InDelta(0.9, g.RejectProbability(), 1e-9)
+
+		clock.Add(30 * time.Second)
+		assert.Equal(float64(0), g.RejectProbability())
+	})
+
+	t.Run("zero window should not panic", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewAdaptiveThrottle(2, 0)
+
+		assert.NoError(g.Run(context.Background(), succeed))
+		assert.Equal(float64(0), g.RejectProbability())
+	})
+
+	t.Run("errors accepted by the accept func should be counted as accepts", func(t *testing.T) {
+		assert := assert.New(t)
+
+		errInvalid := errors.New("invalid argument")
+		clock := newTestClock()
+		g := NewAdaptiveThrottle(1, time.Minute, WithClock(clock), WithAcceptFunc(func(err error) bool {
+			return err == nil || err == errInvalid
+		}))
+
+		for i := 0; i < 9; i++ {
+			g.Run(context.Background(), func(_ context.Context) error {
+				return errInvalid
+			})
+		}
+		assert.Equal(float64(0), g.RejectProbability())
+
+		g.Run(context.Background(), fail)
+		// (10 - 1 * 9) / (10 + 1)
+		assert.InDelta(1.0/11, g.RejectProbability(), 1e-9)
+	})
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// ErrExceedsBurst is a error that is returned when the process can never be
+// executed because it requires more than the limiter allows at once,
+// or the limiter allows nothing more at the rate of 0.
+var ErrExceedsBurst = errors.New("exceeds burst of the limiter")
+
+// TokenBucket is a Limiter with token bucket algorithm.
+type TokenBucket interface {
+	NReserver
+
+	// Rate returns the number of tokens refilled per second.
+	Rate() float64
+
+	// SetRate changes the number of tokens refilled per second.
+	SetRate(r float64)
+
+	// Burst returns the maximum number of tokens in the bucket.
+	Burst() int
+
+	// Tokens returns the number of tokens currently available.
+	// The value becomes negative while there are pending reservations.
+	Tokens() float64
+}
+
+// NewTokenBucket creates a TokenBucket that holds up to burst tokens and
+// is refilled r tokens per second. The bucket is initially full.
+func NewTokenBucket(r float64, burst int, options ...LimiterOption) TokenBucket {
+	o := newLimiterOptions(options)
+	return &tokenBucket{
+		clock:  o.clock,
+		rate:   r,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   o.clock.Now(),
+	}
+}
+
+type tokenBucket struct {
+	clock guard.Clock
+
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func (tb *tokenBucket) Wait(ctx context.Context) error {
+	return tb.WaitN(ctx, 1)
+}
+
+func (tb *tokenBucket) WaitN(ctx context.Context, n int) error {
+	return wait(ctx, func() *reservation { return tb.reserveN(tb.clock.Now(), n) })
+}
+
+func (tb *tokenBucket) Reserve() Reservation {
+	return tb.ReserveN(1)
+}
+
+func (tb *tokenBucket) ReserveN(n int) Reservation {
+	return tb.reserveN(tb.clock.Now(), n)
+}
+
+func (tb *tokenBucket) reserveN(now time.Time, n int) *reservation {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if n <= 0 {
+		return freeReservation(tb.clock, now)
+	}
+	if n > tb.burst {
+		return &reservation{clock: tb.clock}
+	}
+
+	tb.advance(now)
+	tokens := tb.tokens - float64(n)
+
+	var wait time.Duration
+	if tokens < 0 {
+		if tb.rate <= 0 {
+			return &reservation{clock: tb.clock}
+		}
+		wait = durationFromTokens(-tokens, tb.rate)
+	}
+	tb.tokens = tokens
+
+	return &reservation{
+		clock:     tb.clock,
+		ok:        true,
+		timeToAct: now.Add(wait),
+		cancel: func(now time.Time) {
+			tb.mu.Lock()
+			defer tb.mu.Unlock()
+			tb.advance(now)
+			tb.tokens = math.Min(float64(tb.burst), tb.tokens+float64(n))
+		},
+	}
+}
+
+// advance refills the bucket up to now.
+// tb.mu must be held.
+func (tb *tokenBucket) advance(now time.Time) {
+	if now.After(tb.last) {
+		elapsed := now.Sub(tb.last)
+		tb.tokens = math.Min(float64(tb.burst), tb.tokens+elapsed.Seconds()*tb.rate)
+		tb.last = now
+	}
+}
+
+func (tb *tokenBucket) Rate() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.rate
+}
+
+func (tb *tokenBucket) SetRate(r float64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.advance(tb.clock.Now())
+	tb.rate = r
+}
+
+func (tb *tokenBucket) Burst() int {
+	return tb.burst
+}
+
+func (tb *tokenBucket) Tokens() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.advance(tb.clock.Now())
+	return tb.tokens
+}
+
+func durationFromTokens(tokens, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
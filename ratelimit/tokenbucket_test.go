@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard/guardtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClock() *guardtest.Clock {
+	return guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("tokens should be consumed up to burst", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 3, WithClock(clock))
+
+		for i := 0; i < 3; i++ {
+			r := tb.Reserve()
+			assert.True(r.OK())
+			assert.Equal(time.Duration(0), r.Delay())
+		}
+
+		r := tb.Reserve()
+		assert.True(r.OK())
+		assert.Equal(time.Second, r.Delay())
+		assert.Equal(float64(-1), tb.Tokens())
+	})
+
+	t.Run("tokens should be refilled by rate", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(2, 4, WithClock(clock))
+		for i := 0; i < 4; i++ {
+			tb.Reserve()
+		}
+		assert.Equal(float64(0), tb.Tokens())
+
+		clock.Add(time.Second)
+		assert.Equal(float64(2), tb.Tokens())
+
+		clock.Add(time.Hour)
+		assert.Equal(float64(4), tb.Tokens())
+	})
+
+	t.Run("cancel should return tokens", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 1, WithClock(clock))
+		tb.Reserve()
+
+		r := tb.Reserve()
+		assert.Equal(time.Second, r.Delay())
+		r.Cancel()
+		r.Cancel()
+		assert.Equal(float64(0), tb.Tokens())
+
+		r = tb.Reserve()
+		assert.Equal(time.Second, r.Delay())
+	})
+
+	t.Run("set rate should change the refill speed", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 10, WithClock(clock))
+		for i := 0; i < 10; i++ {
+			tb.Reserve()
+		}
+
+		clock.Add(time.Second)
+		tb.SetRate(5)
+		assert.Equal(float64(5), tb.Rate())
+		clock.Add(time.Second)
+
+		assert.Equal(float64(6), tb.Tokens())
+	})
+
+	t.Run("reservation should fail when it exceeds burst or rate is zero", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(0, 1, WithClock(clock))
+
+		assert.True(tb.Reserve().OK())
+		assert.False(tb.Reserve().OK())
+
+		tb = NewTokenBucket(1, 0, WithClock(clock))
+		assert.Equal(ErrExceedsBurst, tb.Wait(context.Background()))
+	})
+
+	t.Run("wait should sleep until the token is available", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 1, WithClock(clock))
+		assert.NoError(tb.Wait(context.Background()))
+
+		done := make(chan error)
+		go func() {
+			done <- tb.Wait(context.Background())
+		}()
+
+		clock.BlockUntil(1)
+		select {
+		case <-done:
+			assert.Fail("wait should be blocked")
+		default:
+		}
+
+		clock.Add(time.Second)
+		assert.NoError(<-done)
+	})
+
+	t.Run("wait should be cancelled by the context", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		tb := NewTokenBucket(1, 1, WithClock(clock))
+		tb.Reserve()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- tb.Wait(ctx)
+		}()
+
+		clock.BlockUntil(1)
+		cancel()
+
+		assert.Equal(context.Canceled, <-done)
+		assert.Equal(float64(0), tb.Tokens())
+	})
+
+	t.Run("wait should fail immediately when the deadline is too short", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tb := NewTokenBucket(0.001, 1)
+		tb.Reserve()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.Equal(context.DeadlineExceeded, tb.Wait(ctx))
+	})
+
+	t.Run("token bucket should work with New", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(NewTokenBucket(1, 1))
+
+		count := 0
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			count++
+			return nil
+		})
+
+		assert.NoError(err)
+		assert.Equal(1, count)
+	})
+}
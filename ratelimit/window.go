@@ -0,0 +1,316 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// NewFixedWindow creates a Reserver that allows up to limit executions
+// in each window of the given size.
+// The windows are aligned to the zero time of Unix, so a burst of up to
+// 2 * limit executions may happen across the boundary of the windows.
+func NewFixedWindow(limit int, size time.Duration, options ...LimiterOption) NReserver {
+	o := newLimiterOptions(options)
+	return &fixedWindow{
+		windowCounter{
+			clock:  o.clock,
+			limit:  limit,
+			size:   size,
+			counts: make(map[int64]int),
+		},
+	}
+}
+
+type fixedWindow struct {
+	windowCounter
+}
+
+func (w *fixedWindow) Wait(ctx context.Context) error {
+	return w.WaitN(ctx, 1)
+}
+
+func (w *fixedWindow) WaitN(ctx context.Context, n int) error {
+	return wait(ctx, func() *reservation { return w.reserveN(w.clock.Now(), n) })
+}
+
+func (w *fixedWindow) Reserve() Reservation {
+	return w.ReserveN(1)
+}
+
+func (w *fixedWindow) ReserveN(n int) Reservation {
+	return w.reserveN(w.clock.Now(), n)
+}
+
+func (w *fixedWindow) reserveN(now time.Time, n int) *reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if n <= 0 {
+		return freeReservation(w.clock, now)
+	}
+	if n > w.limit {
+		return &reservation{clock: w.clock}
+	}
+
+	idx := w.index(now)
+	w.cleanup(idx)
+	for w.counts[idx]+n > w.limit {
+		idx++
+	}
+
+	timeToAct := w.start(idx)
+	if timeToAct.Before(now) {
+		timeToAct = now
+	}
+	return w.reserve(idx, n, timeToAct)
+}
+
+// NewSlidingWindowCounter creates a Reserver that allows up to limit executions
+// in any window of the given size.
+// The number of executions in the window is estimated from the counts of the
+// current and the previous fixed windows by
+//
+//  Count = Previous * (1 - Elapsed / Size) + Current
+//
+// where Elapsed is the time elapsed in the current fixed window.
+func NewSlidingWindowCounter(limit int, size time.Duration, options ...LimiterOption) NReserver {
+	o := newLimiterOptions(options)
+	return &slidingWindowCounter{
+		windowCounter{
+			clock:  o.clock,
+			limit:  limit,
+			size:   size,
+			counts: make(map[int64]int),
+		},
+	}
+}
+
+type slidingWindowCounter struct {
+	windowCounter
+}
+
+func (w *slidingWindowCounter) Wait(ctx context.Context) error {
+	return w.WaitN(ctx, 1)
+}
+
+func (w *slidingWindowCounter) WaitN(ctx context.Context, n int) error {
+	return wait(ctx, func() *reservation { return w.reserveN(w.clock.Now(), n) })
+}
+
+func (w *slidingWindowCounter) Reserve() Reservation {
+	return w.ReserveN(1)
+}
+
+func (w *slidingWindowCounter) ReserveN(n int) Reservation {
+	return w.reserveN(w.clock.Now(), n)
+}
+
+func (w *slidingWindowCounter) reserveN(now time.Time, n int) *reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if n <= 0 {
+		return freeReservation(w.clock, now)
+	}
+	if n > w.limit {
+		return &reservation{clock: w.clock}
+	}
+
+	current := w.index(now)
+	w.cleanup(current - 1)
+	// the reservation must not be placed before the latest reserved window,
+	// whose count was estimated without the reservation.
+	for idx := w.latest(current); ; idx++ {
+		prev, curr := w.counts[idx-1], w.counts[idx]
+		if curr+n > w.limit {
+			continue
+		}
+
+		// the smallest elapsed fraction f that satisfies
+		// prev * (1 - f) + curr + n <= limit.
+		var f float64
+		if prev > 0 {
+			f = math.Max(0, 1-float64(w.limit-curr-n)/float64(prev))
+		}
+		if f >= 1 {
+			continue
+		}
+
+		timeToAct := w.start(idx).Add(time.Duration(math.Ceil(f * float64(w.size))))
+		if timeToAct.Before(now) {
+			timeToAct = now
+		}
+		return w.reserve(idx, n, timeToAct)
+	}
+}
+
+// windowCounter counts executions for each fixed window.
+type windowCounter struct {
+	clock  guard.Clock
+	limit  int
+	size   time.Duration
+	counts map[int64]int
+	mu     sync.Mutex
+}
+
+func (w *windowCounter) index(t time.Time) int64 {
+	return t.UnixNano() / int64(w.size)
+}
+
+func (w *windowCounter) start(idx int64) time.Time {
+	return time.Unix(0, idx*int64(w.size))
+}
+
+// cleanup removes the counts of the windows before idx.
+// w.mu must be held.
+func (w *windowCounter) cleanup(idx int64) {
+	for i := range w.counts {
+		if i < idx {
+			delete(w.counts, i)
+		}
+	}
+}
+
+// latest returns the index of the latest window that has executions,
+// or idx if there is no such window after idx.
+// w.mu must be held.
+func (w *windowCounter) latest(idx int64) int64 {
+	for i, n := range w.counts {
+		if i > idx && n > 0 {
+			idx = i
+		}
+	}
+	return idx
+}
+
+// reserve adds n to the count of the window idx.
+// w.mu must be held.
+func (w *windowCounter) reserve(idx int64, n int, timeToAct time.Time) *reservation {
+	w.counts[idx] += n
+	return &reservation{
+		clock:     w.clock,
+		ok:        true,
+		timeToAct: timeToAct,
+		cancel: func(_ time.Time) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if _, ok := w.counts[idx]; ok {
+				w.counts[idx] -= n
+			}
+		},
+	}
+}
+
+// NewSlidingWindowLog creates a Reserver that allows up to limit executions
+// in any window of the given size.
+// Unlike NewSlidingWindowCounter, the time of each execution is recorded,
+// so the limit is exact at the cost of memory proportional to limit.
+func NewSlidingWindowLog(limit int, size time.Duration, options ...LimiterOption) NReserver {
+	o := newLimiterOptions(options)
+	return &slidingWindowLog{
+		clock: o.clock,
+		limit: limit,
+		size:  size,
+	}
+}
+
+type slidingWindowLog struct {
+	clock guard.Clock
+	limit int
+	size  time.Duration
+	log   []time.Time // sorted in ascending order.
+	mu    sync.Mutex
+}
+
+func (w *slidingWindowLog) Wait(ctx context.Context) error {
+	return w.WaitN(ctx, 1)
+}
+
+func (w *slidingWindowLog) WaitN(ctx context.Context, n int) error {
+	return wait(ctx, func() *reservation { return w.reserveN(w.clock.Now(), n) })
+}
+
+func (w *slidingWindowLog) Reserve() Reservation {
+	return w.ReserveN(1)
+}
+
+func (w *slidingWindowLog) ReserveN(n int) Reservation {
+	return w.reserveN(w.clock.Now(), n)
+}
+
+func (w *slidingWindowLog) reserveN(now time.Time, n int) *reservation {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if n <= 0 {
+		return freeReservation(w.clock, now)
+	}
+	if n > w.limit {
+		return &reservation{clock: w.clock}
+	}
+
+	// remove the executions that no longer belong to the window.
+	expired := sort.Search(len(w.log), func(i int) bool {
+		return w.log[i].Add(w.size).After(now)
+	})
+	w.log = w.log[expired:]
+
+	timeToAct := now
+	// the window ending at timeToAct must contain at most limit-n executions.
+	if i := len(w.log) - (w.limit - n) - 1; i >= 0 {
+		if t := w.log[i].Add(w.size); t.After(timeToAct) {
+			timeToAct = t
+		}
+	}
+
+	w.insert(timeToAct, n)
+	return &reservation{
+		clock:     w.clock,
+		ok:        true,
+		timeToAct: timeToAct,
+		cancel: func(_ time.Time) {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.remove(timeToAct, n)
+		},
+	}
+}
+
+// insert adds n executions at t to the log.
+// w.mu must be held.
+func (w *slidingWindowLog) insert(t time.Time, n int) {
+	l := len(w.log)
+	for j := 0; j < n; j++ {
+		w.log = append(w.log, t)
+	}
+	if l == 0 || !w.log[l-1].After(t) {
+		return
+	}
+
+	// t is earlier than the last entry, e.g. a reservation for the future is made.
+	i := sort.Search(l, func(i int) bool {
+		return w.log[i].After(t)
+	})
+	copy(w.log[i+n:], w.log[i:l])
+	for j := i; j < i+n; j++ {
+		w.log[j] = t
+	}
+}
+
+// remove removes up to n executions at t from the log.
+// w.mu must be held.
+func (w *slidingWindowLog) remove(t time.Time, n int) {
+	i := sort.Search(len(w.log), func(i int) bool {
+		return !w.log[i].Before(t)
+	})
+	j := i
+	for j < len(w.log) && j-i < n && w.log[j].Equal(t) {
+		j++
+	}
+	w.log = append(w.log[:i], w.log[j:]...)
+}
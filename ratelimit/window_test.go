@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedWindow(t *testing.T) {
+	t.Run("executions should be limited in each window", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		clock.Add(500 * time.Millisecond)
+		w := NewFixedWindow(2, time.Second, WithClock(clock))
+
+		assert.Equal(time.Duration(0), w.Reserve().Delay())
+		assert.Equal(time.Duration(0), w.Reserve().Delay())
+		assert.Equal(500*time.Millisecond, w.Reserve().Delay())
+		assert.Equal(500*time.Millisecond, w.Reserve().Delay())
+		assert.Equal(1500*time.Millisecond, w.Reserve().Delay())
+
+		clock.Add(500 * time.Millisecond)
+		assert.Equal(time.Second, w.Reserve().Delay())
+	})
+
+	t.Run("cancel should release the window", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewFixedWindow(1, time.Second, WithClock(clock))
+
+		w.Reserve()
+		r := w.Reserve()
+		assert.Equal(time.Second, r.Delay())
+		r.Cancel()
+
+		assert.Equal(time.Second, w.Reserve().Delay())
+	})
+
+	t.Run("reservation should fail when it exceeds limit", func(t *testing.T) {
+		assert := assert.New(t)
+
+		w := NewFixedWindow(0, time.Second)
+
+		assert.False(w.Reserve().OK())
+		assert.Equal(ErrExceedsBurst, w.Wait(context.Background()))
+	})
+}
+
+func TestSlidingWindowCounter(t *testing.T) {
+	t.Run("executions should be limited by the estimated count", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewSlidingWindowCounter(10, time.Second, WithClock(clock))
+
+		for i := 0; i < 10; i++ {
+			assert.Equal(time.Duration(0), w.Reserve().Delay())
+		}
+
+		// 10 * (1 - 0.1) + 0 + 1 <= 10
+		assert.Equal(1100*time.Millisecond, w.Reserve().Delay())
+		// 10 * (1 - 0.2) + 1 + 1 <= 10
+		assert.Equal(1200*time.Millisecond, w.Reserve().Delay())
+
+		clock.Add(1500 * time.Millisecond)
+		// 10 * (1 - 0.5) + 2 + 1 <= 10
+		assert.Equal(time.Duration(0), w.Reserve().Delay())
+	})
+
+	t.Run("reservation should not exceed the limit of the reserved windows", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewSlidingWindowCounter(10, time.Second, WithClock(clock))
+
+		assert.Equal(time.Duration(0), w.ReserveN(5).Delay())
+		// 5 * (1 - 0.2) + 0 + 6 <= 10
+		assert.Equal(1200*time.Millisecond, w.ReserveN(6).Delay())
+
+		clock.Add(500 * time.Millisecond)
+		// the window [1s, 2s) is full, so 6 * (1 - f) + 0 + 5 <= 10 in [2s, 3s).
+		assert.True(w.ReserveN(5).Delay() >= 1500*time.Millisecond)
+	})
+
+	t.Run("cancel should release the window", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewSlidingWindowCounter(1, time.Second, WithClock(clock))
+
+		w.Reserve()
+		r := w.Reserve()
+		assert.Equal(2*time.Second, r.Delay())
+		r.Cancel()
+
+		assert.Equal(2*time.Second, w.Reserve().Delay())
+	})
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	t.Run("executions should be limited in any window", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewSlidingWindowLog(2, time.Second, WithClock(clock))
+
+		assert.Equal(time.Duration(0), w.Reserve().Delay())
+		clock.Add(300 * time.Millisecond)
+		assert.Equal(time.Duration(0), w.Reserve().Delay())
+		assert.Equal(700*time.Millisecond, w.Reserve().Delay())
+		assert.Equal(time.Second, w.Reserve().Delay())
+		assert.Equal(1700*time.Millisecond, w.Reserve().Delay())
+
+		clock.Add(time.Hour)
+		assert.Equal(time.Duration(0), w.Reserve().Delay())
+	})
+
+	t.Run("cancel should release the window", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewSlidingWindowLog(1, time.Second, WithClock(clock))
+
+		w.Reserve()
+		r := w.Reserve()
+		assert.Equal(time.Second, r.Delay())
+		r.Cancel()
+
+		assert.Equal(time.Second, w.Reserve().Delay())
+	})
+
+	t.Run("wait should sleep until the window slides", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		w := NewSlidingWindowLog(1, time.Second, WithClock(clock))
+		assert.NoError(w.Wait(context.Background()))
+
+		done := make(chan error)
+		go func() {
+			done <- w.Wait(context.Background())
+		}()
+		clock.BlockUntil(1)
+
+		clock.Add(time.Second)
+		assert.NoError(<-done)
+	})
+
+	t.Run("log should be kept sorted", func(t *testing.T) {
+		assert := assert.New(t)
+
+		base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+		at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+		w := &slidingWindowLog{}
+		w.insert(at(1), 1)
+		w.insert(at(3), 2)
+		w.insert(at(2), 2)
+		w.insert(at(3), 1)
+		w.insert(at(0), 1)
+
+		assert.Equal([]time.Time{at(0), at(1), at(2), at(2), at(3), at(3), at(3)}, w.log)
+	})
+}
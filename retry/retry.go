@@ -12,16 +12,25 @@ import (
 var Inf int = -1
 
 // New creates a new guard.Guard with retry capability.
-func New(n int, backoff guard.Backoff) guard.Guard {
+// It stops retrying when the backoff stops, so that n can be Inf
+// to let the backoff decide the number of retries.
+func New(n int, backoff guard.Backoff, opts ...Option) guard.Guard {
+	o := newOptions(opts)
+
 	return guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
 		err := f(ctx)
 		if err == nil {
 			return nil
 		}
 
-		bo := backoff.Reset()
+		interval := intervals(backoff)
 		for i := 0; i < n || n < 0; i++ {
-			err := sleep(ctx, bo.NextInterval())
+			d, ok := interval(i + 1)
+			if !ok {
+				break
+			}
+
+			err := sleep(ctx, o.clock, d)
 			if err != nil {
 				return err
 			}
@@ -35,13 +44,60 @@ func New(n int, backoff guard.Backoff) guard.Guard {
 	})
 }
 
-func sleep(ctx context.Context, d time.Duration) error {
-	t := time.NewTimer(d)
+// intervals returns the function that returns the interval before the n-th retry.
+// guard.AttemptBackoff is used as is, so that the backoff is not cloned for each run.
+func intervals(backoff guard.Backoff) func(n int) (time.Duration, bool) {
+	if a, ok := backoff.(guard.AttemptBackoff); ok {
+		return a.Interval
+	}
+	bo := backoff.Reset()
+	return func(_ int) (time.Duration, bool) {
+		return guard.Next(bo)
+	}
+}
+
+// sleep sleeps for d or until the context is done.
+// The context is checked first because select chooses randomly
+// when both the context and the timer are ready.
+func sleep(ctx context.Context, clock guard.Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+
+	t := clock.NewTimer(d)
 	defer t.Stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-t.C:
+	case <-t.C():
 		return nil
 	}
 }
+
+type options struct {
+	clock guard.Clock
+}
+
+// Option is the optional parameter for New.
+type Option func(*options)
+
+// WithClock set the clock used to wait for the intervals.
+// The default is guard.SystemClock.
+func WithClock(c guard.Clock) Option {
+	return Option(func(o *options) {
+		o.clock = c
+	})
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		clock: guard.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
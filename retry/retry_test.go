@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/guardtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,4 +79,56 @@ func TestRetry(t *testing.T) {
 		assert.Equal(context.Canceled, err)
 		assert.Equal(2, count)
 	})
+
+	t.Run("retry should be stopped when the backoff returns stop", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(Inf, guard.NewMaxRetriesBackoff(noBackoff, 2))
+
+		count := 0
+		err := g.Run(context.Background(), func(ctx context.Context) error {
+			count++
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+		assert.Equal(3, count)
+	})
+
+	t.Run("intervals should be waited by the clock", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+		g := New(2, guard.NewConstantBackoff(time.Hour), WithClock(clock))
+
+		count := 0
+		done := make(chan error)
+		go func() {
+			done <- g.Run(context.Background(), func(ctx context.Context) error {
+				count++
+				return errors.New("test error")
+			})
+		}()
+
+		clock.BlockUntil(1)
+		clock.Add(time.Hour)
+		clock.BlockUntil(1)
+		clock.Add(time.Hour)
+
+		assert.EqualError(<-done, "test error")
+		assert.Equal(3, count)
+	})
+
+	t.Run("intervals should be requested from the reset backoff", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b := guardtest.NewBackoff(0, 0, 0)
+		g := New(Inf, b)
+
+		err := g.Run(context.Background(), guardtest.FailTimes(2, errors.New("test error")))
+
+		assert.NoError(err)
+		guardtest.AssertRequested(t, b, 0, 0)
+		assert.Equal(1, b.Resets())
+	})
 }
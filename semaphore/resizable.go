@@ -0,0 +1,127 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/morikuni/guard"
+)
+
+// ResizableSemaphore is a guard.Guard with a limit that can be changed at runtime.
+type ResizableSemaphore interface {
+	guard.Guard
+
+	// Limit returns the current limit of concurrent processes.
+	Limit() int
+
+	// InUse returns the number of processes currently running.
+	InUse() int
+
+	// SetLimit changes the limit of concurrent processes.
+	// When the limit is lowered, running processes are not interrupted,
+	// and new processes wait until the number of running processes
+	// falls below the new limit.
+	SetLimit(n int)
+}
+
+// NewResizable creates a new ResizableSemaphore with initial limit n.
+func NewResizable(n int) ResizableSemaphore {
+	if n < 0 {
+		n = 0
+	}
+	return &resizableSemaphore{
+		limit:   n,
+		waiters: list.New(),
+	}
+}
+
+type resizableSemaphore struct {
+	limit   int
+	inUse   int
+	waiters *list.List // list of chan struct{}
+	mu      sync.Mutex
+}
+
+func (s *resizableSemaphore) Run(ctx context.Context, f func(context.Context) error) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+	return f(ctx)
+}
+
+func (s *resizableSemaphore) acquire(ctx context.Context) error {
+	s.mu.Lock()
+	if s.inUse < s.limit && s.waiters.Len() == 0 {
+		s.inUse++
+		s.mu.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// acquired while being cancelled, give it back.
+			s.inUse--
+			s.notify()
+		default:
+			s.waiters.Remove(elem)
+			// removing the head may allow the following waiters to proceed.
+			s.notify()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *resizableSemaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inUse--
+	s.notify()
+}
+
+// notify wakes up waiters as long as there is a room.
+// s.mu must be held.
+func (s *resizableSemaphore) notify() {
+	for s.inUse < s.limit {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		s.waiters.Remove(front)
+		s.inUse++
+		close(front.Value.(chan struct{}))
+	}
+}
+
+func (s *resizableSemaphore) Limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limit
+}
+
+func (s *resizableSemaphore) InUse() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inUse
+}
+
+func (s *resizableSemaphore) SetLimit(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = n
+	s.notify()
+}
@@ -0,0 +1,102 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResizableSemaphore(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewResizable(3)
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+		assert.Equal(0, g.InUse())
+	})
+
+	t.Run("err should be returnd immediately when the context is cancelled", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewResizable(0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := g.Run(ctx, func(ctx context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.Equal(context.Canceled, err)
+		assert.Equal(0, g.InUse())
+	})
+
+	t.Run("limit should be changed at runtime", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewResizable(2)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var count int32 = 0
+		for i := 0; i < 10; i++ {
+			go g.Run(ctx, func(ctx context.Context) error {
+				atomic.AddInt32(&count, 1)
+				<-ctx.Done()
+				return nil
+			})
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(int32(2), atomic.LoadInt32(&count))
+		assert.Equal(2, g.InUse())
+
+		g.SetLimit(5)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(int32(5), atomic.LoadInt32(&count))
+		assert.Equal(5, g.InUse())
+		assert.Equal(5, g.Limit())
+	})
+
+	t.Run("running processes should finish when the limit is lowered", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewResizable(3)
+
+		release := make(chan struct{})
+		var count int32 = 0
+		for i := 0; i < 6; i++ {
+			go g.Run(context.Background(), func(ctx context.Context) error {
+				atomic.AddInt32(&count, 1)
+				<-release
+				return nil
+			})
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(int32(3), atomic.LoadInt32(&count))
+
+		g.SetLimit(1)
+		assert.Equal(3, g.InUse())
+
+		// finish all running processes, then only 1 waiter can run.
+		for i := 0; i < 3; i++ {
+			release <- struct{}{}
+		}
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(int32(4), atomic.LoadInt32(&count))
+		assert.Equal(1, g.InUse())
+
+		close(release)
+	})
+}
@@ -0,0 +1,103 @@
+package shedding
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// NewCoDel creates a new guard.Guard that runs up to limit processes
+// concurrently, and sheds the queued processes by controlled delay.
+//
+// The queue time of the process is measured from the arrival time held by
+// the context, or from the time it reached the guard.
+// When the minimum queue time in the last interval exceeds target,
+// the guard regards the service as overloaded and rejects the process
+// that has waited longer than target with ErrOverloaded.
+// Otherwise the process can wait up to interval.
+func NewCoDel(limit int, target, interval time.Duration, opts ...Option) guard.Guard {
+	o := newOptions(opts)
+	return &codel{
+		clock:    o.clock,
+		slots:    make(chan struct{}, limit),
+		target:   target,
+		interval: interval,
+	}
+}
+
+type codel struct {
+	clock    guard.Clock
+	slots    chan struct{}
+	target   time.Duration
+	interval time.Duration
+
+	overloaded    bool
+	minDelay      time.Duration
+	intervalStart time.Time
+	mu            sync.Mutex
+}
+
+func (c *codel) Run(ctx context.Context, f func(context.Context) error) error {
+	arrival, ok := ArrivalTimeFromContext(ctx)
+	if !ok {
+		arrival = c.clock.Now()
+	}
+
+	timeout := c.timeout() - c.clock.Now().Sub(arrival)
+	if err := c.acquire(ctx, timeout); err != nil {
+		return err
+	}
+	defer func() { <-c.slots }()
+
+	c.observe(c.clock.Now().Sub(arrival))
+	return f(ctx)
+}
+
+func (c *codel) acquire(ctx context.Context, timeout time.Duration) error {
+	if timeout <= 0 {
+		return ErrOverloaded
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	t := c.clock.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-t.C():
+		return ErrOverloaded
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *codel) timeout() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overloaded {
+		return c.target
+	}
+	return c.interval
+}
+
+func (c *codel) observe(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if now.Sub(c.intervalStart) >= c.interval {
+		c.overloaded = !c.intervalStart.IsZero() && c.minDelay > c.target
+		c.intervalStart = now
+		c.minDelay = delay
+		return
+	}
+	if delay < c.minDelay {
+		c.minDelay = delay
+	}
+}
@@ -0,0 +1,133 @@
+package shedding
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// NewLatency creates a new guard.Guard that sheds the process according to
+// the latency of the recent processes run through the guard.
+//
+// The guard keeps the latencies of up to size processes finished in the
+// last window, and when the given percentile (e.g. 0.99) of them exceeds
+// threshold, rejects the process with ErrOverloaded by the probability
+//
+//  min(1, (Latency - Threshold) / Threshold)
+//
+// The percentile is clamped to [0, 1], and size is at least 1.
+// The process that returns context.Canceled is not sampled.
+// The old samples expire after window, so the guard recovers even if
+// all processes are rejected.
+func NewLatency(threshold time.Duration, percentile float64, size int, window time.Duration, opts ...Option) guard.Guard {
+	o := newOptions(opts)
+	if size < 1 {
+		size = 1
+	}
+	return &latency{
+		clock:      o.clock,
+		randomizer: o.randomizer,
+		threshold:  threshold,
+		percentile: math.Max(0, math.Min(1, percentile)),
+		window:     window,
+		samples:    make([]sample, size),
+		sorted:     make([]time.Duration, 0, size),
+	}
+}
+
+type sample struct {
+	latency time.Duration
+	at      time.Time
+}
+
+type latency struct {
+	clock      guard.Clock
+	randomizer guard.Randomizer
+	threshold  time.Duration
+	percentile float64
+	window     time.Duration
+
+	samples []sample // ring buffer in the order of time, starting at head.
+	head    int
+	count   int
+	sorted  []time.Duration // latencies of the samples in ascending order.
+	mu      sync.Mutex
+}
+
+func (l *latency) Run(ctx context.Context, f func(context.Context) error) error {
+	if p := l.rejectProbability(); p > 0 && l.randomizer.Float64() < p {
+		return ErrOverloaded
+	}
+
+	start := l.clock.Now()
+	err := f(ctx)
+	if err != context.Canceled {
+		l.put(l.clock.Now().Sub(start))
+	}
+	return err
+}
+
+func (l *latency) rejectProbability() float64 {
+	d := l.percentileLatency()
+	if d <= l.threshold {
+		return 0
+	}
+	return math.Min(1, float64(d-l.threshold)/float64(l.threshold))
+}
+
+// percentileLatency returns the latency at the percentile of the samples in the window.
+func (l *latency) percentileLatency() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.expire(l.clock.Now())
+	if len(l.sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(l.percentile*float64(len(l.sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return l.sorted[i]
+}
+
+func (l *latency) put(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.expire(now)
+	if l.count == len(l.samples) {
+		l.pop()
+	}
+	l.samples[(l.head+l.count)%len(l.samples)] = sample{d, now}
+	l.count++
+
+	i := sort.Search(len(l.sorted), func(i int) bool { return l.sorted[i] > d })
+	l.sorted = append(l.sorted, 0)
+	copy(l.sorted[i+1:], l.sorted[i:])
+	l.sorted[i] = d
+}
+
+// expire removes the samples out of the window.
+// l.mu must be held.
+func (l *latency) expire(now time.Time) {
+	for l.count > 0 && now.Sub(l.samples[l.head].at) >= l.window {
+		l.pop()
+	}
+}
+
+// pop removes the oldest sample.
+// l.mu must be held.
+func (l *latency) pop() {
+	d := l.samples[l.head].latency
+	l.head = (l.head + 1) % len(l.samples)
+	l.count--
+
+	i := sort.Search(len(l.sorted), func(i int) bool { return l.sorted[i] >= d })
+	l.sorted = append(l.sorted[:i], l.sorted[i+1:]...)
+}
@@ -0,0 +1,64 @@
+// Package shedding provides guards that reject the process early when the service is overloaded.
+package shedding
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// ErrOverloaded is a error that is returned when the process is rejected
+// because the service is overloaded.
+var ErrOverloaded = errors.New("overloaded")
+
+type arrivalKey struct{}
+
+// WithArrivalTime returns a new context that holds the time when the request arrived,
+// e.g. when the server accepted the request.
+// The time spent before reaching the guard is counted as the queue time.
+func WithArrivalTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, arrivalKey{}, t)
+}
+
+// ArrivalTimeFromContext returns the arrival time held by the context.
+func ArrivalTimeFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(arrivalKey{}).(time.Time)
+	return t, ok
+}
+
+type options struct {
+	clock      guard.Clock
+	randomizer guard.Randomizer
+}
+
+// Option is the optional parameter for the guards in this package.
+type Option func(*options)
+
+// WithClock set the clock used by the guard.
+// The default is guard.SystemClock.
+func WithClock(c guard.Clock) Option {
+	return Option(func(o *options) {
+		o.clock = c
+	})
+}
+
+// WithRandomizer set the randomizer used by the guard.
+// The default is guard.NewRandomizer().
+func WithRandomizer(r guard.Randomizer) Option {
+	return Option(func(o *options) {
+		o.randomizer = r
+	})
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		clock:      guard.SystemClock,
+		randomizer: guard.NewRandomizer(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
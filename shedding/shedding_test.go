@@ -0,0 +1,135 @@
+package shedding
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/guardtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClock() *guardtest.Clock {
+	return guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+type testRandomizer float64
+
+func (r testRandomizer) Float64() float64 {
+	return float64(r)
+}
+
+func succeed(_ context.Context) error {
+	return nil
+}
+
+func TestCoDel(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewCoDel(1, 10*time.Millisecond, 100*time.Millisecond)
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("process waited too long should be shed while overloaded", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewCoDel(1, 10*time.Millisecond, 100*time.Millisecond, WithClock(clock))
+		arrived := func(d time.Duration) context.Context {
+			return WithArrivalTime(context.Background(), clock.Now().Add(-d))
+		}
+
+		assert.NoError(g.Run(arrived(60*time.Millisecond), succeed))
+		assert.Equal(ErrOverloaded, g.Run(arrived(200*time.Millisecond), succeed))
+
+		clock.Add(100 * time.Millisecond)
+		// the minimum delay of the last interval was 60ms.
+		assert.NoError(g.Run(arrived(20*time.Millisecond), succeed))
+		assert.Equal(ErrOverloaded, g.Run(arrived(20*time.Millisecond), succeed))
+		assert.NoError(g.Run(context.Background(), succeed))
+
+		clock.Add(100 * time.Millisecond)
+		// the minimum delay of the last interval was 0.
+		assert.NoError(g.Run(arrived(5*time.Millisecond), succeed))
+		assert.NoError(g.Run(arrived(20*time.Millisecond), succeed))
+	})
+
+	t.Run("queued process should be shed after the timeout", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewCoDel(1, time.Millisecond, 10*time.Millisecond)
+
+		release := make(chan struct{})
+		go g.Run(context.Background(), func(_ context.Context) error {
+			<-release
+			return nil
+		})
+		time.Sleep(5 * time.Millisecond)
+
+		assert.Equal(ErrOverloaded, g.Run(context.Background(), succeed))
+		close(release)
+	})
+}
+
+func TestLatency(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := NewLatency(time.Second, 0.99, 100, time.Minute)
+
+		err := g.Run(context.Background(), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("process should be shed when the latency exceeds threshold", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		g := NewLatency(100*time.Millisecond, 0.5, 4, time.Minute, WithClock(clock), WithRandomizer(testRandomizer(0.3)))
+		slow := func(d time.Duration) func(context.Context) error {
+			return func(_ context.Context) error {
+				clock.Add(d)
+				return nil
+			}
+		}
+
+		g.Run(context.Background(), slow(10*time.Millisecond))
+		g.Run(context.Background(), slow(10*time.Millisecond))
+		g.Run(context.Background(), slow(140*time.Millisecond))
+		// median is 10ms.
+		assert.NoError(g.Run(context.Background(), slow(140*time.Millisecond)))
+		assert.NoError(g.Run(context.Background(), slow(250*time.Millisecond)))
+		// median is 140ms, the probability is 0.4.
+		assert.Equal(ErrOverloaded, g.Run(context.Background(), succeed))
+
+		clock.Add(time.Minute)
+		assert.NoError(g.Run(context.Background(), succeed))
+	})
+
+	t.Run("invalid parameters should be clamped", func(t *testing.T) {
+		assert := assert.New(t)
+
+		clock := newTestClock()
+		for _, g := range []guard.Guard{
+			NewLatency(100*time.Millisecond, 1.5, 4, time.Minute, WithClock(clock), WithRandomizer(testRandomizer(0.3))),
+			NewLatency(100*time.Millisecond, 0.5, 0, time.Minute, WithClock(clock), WithRandomizer(testRandomizer(0.3))),
+		} {
+			assert.NoError(g.Run(context.Background(), func(_ context.Context) error {
+				clock.Add(time.Second)
+				return nil
+			}))
+			assert.Equal(ErrOverloaded, g.Run(context.Background(), succeed))
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package simulation
+
+import (
+	"math"
+	"time"
+
+	"github.com/morikuni/guard"
+)
+
+// Load is a load profile that returns the interval until the next request
+// arrives at elapsed since the start of the simulation.
+// The interval must be positive.
+type Load func(elapsed time.Duration, r guard.Randomizer) time.Duration
+
+// ConstantLoad creates Load with rps requests per second at regular intervals.
+func ConstantLoad(rps float64) Load {
+	return Load(func(_ time.Duration, _ guard.Randomizer) time.Duration {
+		return perSecond(rps)
+	})
+}
+
+// PoissonLoad creates Load with rps requests per second on average,
+// whose intervals follow the exponential distribution.
+func PoissonLoad(rps float64) Load {
+	return Load(func(_ time.Duration, r guard.Randomizer) time.Duration {
+		d := time.Duration(-math.Log(1-r.Float64()) * float64(time.Second) / rps)
+		if d <= 0 {
+			return 1
+		}
+		return d
+	})
+}
+
+// RampLoad creates Load that changes the requests per second linearly
+// from the from to the to over d, and keeps the to after that.
+func RampLoad(from, to float64, d time.Duration) Load {
+	return Load(func(elapsed time.Duration, _ guard.Randomizer) time.Duration {
+		if elapsed >= d {
+			return perSecond(to)
+		}
+		return perSecond(from + (to-from)*float64(elapsed)/float64(d))
+	})
+}
+
+func perSecond(rps float64) time.Duration {
+	d := time.Duration(float64(time.Second) / rps)
+	if d <= 0 {
+		return 1
+	}
+	return d
+}
+
+// Dependency is a model of the dependency called by the requests.
+type Dependency struct {
+	// Latency returns the latency of a call at elapsed since the start of the simulation.
+	// The latency is 0 if it is nil.
+	Latency func(elapsed time.Duration, r guard.Randomizer) time.Duration
+
+	// ErrorRate returns the probability that a call at elapsed
+	// since the start of the simulation fails with ErrDependency.
+	// The call never fails if it is nil.
+	ErrorRate func(elapsed time.Duration) float64
+}
+
+// ConstantLatency returns the latency model that always takes d.
+func ConstantLatency(d time.Duration) func(time.Duration, guard.Randomizer) time.Duration {
+	return func(_ time.Duration, _ guard.Randomizer) time.Duration {
+		return d
+	}
+}
+
+// UniformLatency returns the latency model that takes [min, max) uniformly.
+func UniformLatency(min, max time.Duration) func(time.Duration, guard.Randomizer) time.Duration {
+	return func(_ time.Duration, r guard.Randomizer) time.Duration {
+		return min + time.Duration(float64(max-min)*r.Float64())
+	}
+}
+
+// ConstantErrorRate returns the error rate model that always fails with probability p.
+func ConstantErrorRate(p float64) func(time.Duration) float64 {
+	return func(_ time.Duration) float64 {
+		return p
+	}
+}
+
+// OutageErrorRate returns the error rate model that fails with probability p
+// during [from, to), and never fails otherwise.
+func OutageErrorRate(from, to time.Duration, p float64) func(time.Duration) float64 {
+	return func(elapsed time.Duration) float64 {
+		if elapsed >= from && elapsed < to {
+			return p
+		}
+		return 0
+	}
+}
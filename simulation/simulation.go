@@ -0,0 +1,334 @@
+// Package simulation provides a deterministic simulation of guards on a virtual clock.
+//
+// Simulation drives a guard with a synthetic load against a modeled dependency,
+// and reports how the guard behaves, such as the success rate and the load
+// amplification caused by retries.
+//
+// The guards under simulation must wait only by the clock and the randomizer of
+// the Simulation, for example retry.WithClock, circuitbreaker.WithClock,
+// ratelimit.WithClock and adaptive.WithClock. The requests are processed one at
+// a time in the order of the virtual time, so that the same seed always produces
+// the same report.
+package simulation
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/circuitbreaker"
+	"github.com/morikuni/guard/guardtest"
+)
+
+// Simulation is a simulation of guards on a virtual clock.
+type Simulation struct {
+	duration   time.Duration
+	load       Load
+	dependency Dependency
+	clock      *guardtest.Clock
+	randomizer guard.Randomizer
+
+	breakers []observedBreaker
+	inflight int64
+	calls    int64
+}
+
+type observedBreaker struct {
+	name   string
+	events <-chan circuitbreaker.StateChange
+}
+
+// New creates a new Simulation.
+//
+// The default parameters.
+//
+//  Duration:   1 (min)
+//  Load:       ConstantLoad(10)
+//  Dependency: Dependency{Latency: ConstantLatency(10 * time.Millisecond)}
+//  Seed:       1
+func New(options ...Option) *Simulation {
+	s := &Simulation{
+		duration: time.Minute,
+		load:     ConstantLoad(10),
+		dependency: Dependency{
+			Latency: ConstantLatency(10 * time.Millisecond),
+		},
+		clock:      guardtest.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)),
+		randomizer: guard.NewSeededRandomizer(1),
+	}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// Option is the optional parameter for New.
+type Option func(*Simulation)
+
+// WithDuration set the duration in which the requests arrive.
+func WithDuration(d time.Duration) Option {
+	return Option(func(s *Simulation) {
+		s.duration = d
+	})
+}
+
+// WithLoad set the load profile of the requests.
+func WithLoad(l Load) Option {
+	return Option(func(s *Simulation) {
+		s.load = l
+	})
+}
+
+// WithDependency set the model of the dependency called by the requests.
+func WithDependency(d Dependency) Option {
+	return Option(func(s *Simulation) {
+		s.dependency = d
+	})
+}
+
+// WithSeed set the seed of the randomizer.
+func WithSeed(seed int64) Option {
+	return Option(func(s *Simulation) {
+		s.randomizer = guard.NewSeededRandomizer(seed)
+	})
+}
+
+// Clock returns the virtual clock that should be passed to the guards.
+func (s *Simulation) Clock() guard.Clock {
+	return s.clock
+}
+
+// Randomizer returns the seeded randomizer that should be passed to the guards.
+func (s *Simulation) Randomizer() guard.Randomizer {
+	return s.randomizer
+}
+
+// Observe records the state changes of cb in the report with name.
+// It must be called before Run, and cb must not be subscribed by others.
+func (s *Simulation) Observe(name string, cb circuitbreaker.CircuitBreaker) {
+	s.breakers = append(s.breakers, observedBreaker{name, cb.Subscribe()})
+}
+
+// Run runs the simulation with g and returns the report.
+// It returns after all requests are finished.
+func (s *Simulation) Run(g guard.Guard) Report {
+	start := s.clock.Now()
+	end := start.Add(s.duration)
+	r := &recorder{}
+
+	arrival := start
+	for {
+		s.settle()
+		s.drain(start, r)
+
+		next, ok := s.clock.Next()
+		if arrival.Before(end) && (!ok || arrival.Before(next)) {
+			s.clock.Set(arrival)
+			s.start(g, start, r)
+			arrival = arrival.Add(s.load(arrival.Sub(start), s.randomizer))
+			continue
+		}
+
+		if atomic.LoadInt64(&s.inflight) == 0 && !arrival.Before(end) {
+			break
+		}
+		if !s.clock.Step() {
+			// never come here because the requests in flight wait on the clock.
+			panic("simulation: no timer to advance")
+		}
+	}
+
+	return r.report(atomic.LoadInt64(&s.calls), s.clock.Now().Sub(start))
+}
+
+func (s *Simulation) start(g guard.Guard, start time.Time, r *recorder) {
+	arrival := s.clock.Now()
+	atomic.AddInt64(&s.inflight, 1)
+	go func() {
+		defer atomic.AddInt64(&s.inflight, -1)
+		err := g.Run(context.Background(), s.call(start))
+		r.record(err, s.clock.Now().Sub(arrival))
+	}()
+}
+
+// call returns the function that calls the modeled dependency.
+func (s *Simulation) call(start time.Time) func(context.Context) error {
+	return func(ctx context.Context) error {
+		atomic.AddInt64(&s.calls, 1)
+
+		elapsed := s.clock.Now().Sub(start)
+		failed := s.dependency.ErrorRate != nil && s.randomizer.Float64() < s.dependency.ErrorRate(elapsed)
+		latency := time.Duration(0)
+		if s.dependency.Latency != nil {
+			latency = s.dependency.Latency(elapsed, s.randomizer)
+		}
+
+		t := s.clock.NewTimer(latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+
+		if failed {
+			return ErrDependency
+		}
+		return nil
+	}
+}
+
+// settle waits until all requests in flight wait on the clock.
+func (s *Simulation) settle() {
+	watchdog := time.Now()
+	for i := 0; s.clock.Sleepers() < int(atomic.LoadInt64(&s.inflight)); i++ {
+		runtime.Gosched()
+		if i%1000 == 0 && time.Since(watchdog) > 10*time.Second {
+			panic("simulation: guard is blocked without waiting on the clock")
+		}
+	}
+}
+
+// drain records the state changes of the circuit breakers.
+func (s *Simulation) drain(start time.Time, r *recorder) {
+	at := s.clock.Now().Sub(start)
+	for _, b := range s.breakers {
+		for drained := false; !drained; {
+			select {
+			case sc := <-b.events:
+				r.transitions = append(r.transitions, Transition{at, b.name, sc})
+			default:
+				drained = true
+			}
+		}
+	}
+}
+
+// ErrDependency is a error that is returned by the modeled dependency when it fails.
+var ErrDependency = errors.New("dependency failed")
+
+type recorder struct {
+	latencies   []time.Duration
+	errors      map[string]int
+	transitions []Transition
+	mu          sync.Mutex
+}
+
+func (r *recorder) record(err error, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, latency)
+	if err != nil {
+		if r.errors == nil {
+			r.errors = make(map[string]int)
+		}
+		r.errors[err.Error()]++
+	}
+}
+
+func (r *recorder) report(calls int64, elapsed time.Duration) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := Report{
+		Requests:    len(r.latencies),
+		Calls:       int(calls),
+		Elapsed:     elapsed,
+		Errors:      r.errors,
+		Transitions: r.transitions,
+	}
+	for _, n := range r.errors {
+		rep.Failed += n
+	}
+	rep.Succeeded = rep.Requests - rep.Failed
+	if rep.Requests > 0 {
+		rep.SuccessRate = float64(rep.Succeeded) / float64(rep.Requests)
+		rep.LoadAmplification = float64(rep.Calls) / float64(rep.Requests)
+	}
+	rep.Latency = newLatency(r.latencies)
+	return rep
+}
+
+// Report is the result of a simulation.
+type Report struct {
+	// Requests is the number of the requests.
+	Requests int
+
+	// Succeeded is the number of the requests that succeeded.
+	Succeeded int
+
+	// Failed is the number of the requests that failed.
+	Failed int
+
+	// SuccessRate is Succeeded / Requests.
+	SuccessRate float64
+
+	// Calls is the number of the calls to the dependency.
+	Calls int
+
+	// LoadAmplification is Calls / Requests.
+	// It is greater than 1 when the guard retries, and less than 1 when
+	// the guard rejects the requests.
+	LoadAmplification float64
+
+	// Latency is the latency of the requests including the time in the guard.
+	Latency Latency
+
+	// Errors is the number of the errors by the messages.
+	Errors map[string]int
+
+	// Transitions is the state changes of the observed circuit breakers.
+	Transitions []Transition
+
+	// Elapsed is the virtual time taken to finish all requests.
+	Elapsed time.Duration
+}
+
+// Latency is the summary of the latencies.
+type Latency struct {
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+func newLatency(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, l := range sorted {
+		sum += l
+	}
+	percentile := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return Latency{
+		Mean: sum / time.Duration(len(sorted)),
+		P50:  percentile(0.5),
+		P90:  percentile(0.9),
+		P99:  percentile(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// Transition is a state change of a circuit breaker.
+type Transition struct {
+	// At is the virtual time since the start of the simulation.
+	At time.Duration
+
+	// Name is the name given by Observe.
+	Name string
+
+	// Change is the state change.
+	Change circuitbreaker.StateChange
+}
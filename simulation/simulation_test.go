@@ -0,0 +1,98 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/morikuni/guard/circuitbreaker"
+	"github.com/morikuni/guard/ratelimit"
+	"github.com/morikuni/guard/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+var noGuard = guard.GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
+	return f(ctx)
+})
+
+func TestSimulation(t *testing.T) {
+	t.Run("requests should follow the load and the dependency", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := New(
+			WithDuration(10*time.Second),
+			WithLoad(ConstantLoad(10)),
+			WithDependency(Dependency{
+				Latency:   ConstantLatency(100 * time.Millisecond),
+				ErrorRate: OutageErrorRate(5*time.Second, 10*time.Second, 1),
+			}),
+		)
+
+		r := s.Run(noGuard)
+
+		assert.Equal(100, r.Requests)
+		assert.Equal(50, r.Succeeded)
+		assert.Equal(50, r.Failed)
+		assert.Equal(0.5, r.SuccessRate)
+		assert.Equal(1.0, r.LoadAmplification)
+		assert.Equal(map[string]int{ErrDependency.Error(): 50}, r.Errors)
+		assert.Equal(Latency{
+			Mean: 100 * time.Millisecond,
+			P50:  100 * time.Millisecond,
+			P90:  100 * time.Millisecond,
+			P99:  100 * time.Millisecond,
+			Max:  100 * time.Millisecond,
+		}, r.Latency)
+		assert.Equal(10*time.Second, r.Elapsed)
+	})
+
+	t.Run("composed guard should be simulated deterministically", func(t *testing.T) {
+		assert := assert.New(t)
+
+		run := func() Report {
+			s := New(
+				WithDuration(time.Minute),
+				WithLoad(PoissonLoad(20)),
+				WithDependency(Dependency{
+					Latency:   UniformLatency(10*time.Millisecond, 50*time.Millisecond),
+					ErrorRate: OutageErrorRate(10*time.Second, 20*time.Second, 0.9),
+				}),
+				WithSeed(42),
+			)
+
+			cb := circuitbreaker.New(
+				circuitbreaker.NewCountBaseWindow(10),
+				0.5,
+				guard.NewConstantBackoff(time.Second),
+				circuitbreaker.WithClock(s.Clock()),
+			)
+			s.Observe("cb", cb)
+
+			backoff := guard.NewFullJitterBackoff(
+				guard.WithInitialInterval(100*time.Millisecond),
+				guard.WithRandomizer(s.Randomizer()),
+			)
+			g := guard.Compose(
+				retry.New(3, backoff, retry.WithClock(s.Clock())),
+				ratelimit.New(ratelimit.NewTokenBucket(100, 10, ratelimit.WithClock(s.Clock()))),
+				cb,
+			)
+			return s.Run(g)
+		}
+
+		r := run()
+
+		assert.Equal(run(), r)
+		assert.True(r.SuccessRate > 0.5 && r.SuccessRate < 1, "%v", r.SuccessRate)
+		assert.Equal(r.Calls, int(r.LoadAmplification*float64(r.Requests)+0.5))
+		assert.NotZero(r.Errors[circuitbreaker.ErrCircuitBreakerOpen.Error()])
+		assert.True(r.Latency.Max > 100*time.Millisecond, "%v", r.Latency.Max)
+		if assert.NotEmpty(r.Transitions) {
+			assert.Equal(circuitbreaker.CloseToOpen, r.Transitions[0].Change)
+			assert.Equal("cb", r.Transitions[0].Name)
+			assert.True(r.Transitions[0].At >= 10*time.Second)
+			assert.Equal(circuitbreaker.HalfOpenToClose, r.Transitions[len(r.Transitions)-1].Change)
+		}
+	})
+}
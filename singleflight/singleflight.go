@@ -0,0 +1,139 @@
+// Package singleflight provides a guard that coalesces concurrent processes sharing a key.
+package singleflight
+
+import (
+	"context"
+	"sync"
+
+	"github.com/morikuni/guard"
+)
+
+// WithKey is the same as guard.WithKey.
+func WithKey(ctx context.Context, key string) context.Context {
+	return guard.WithKey(ctx, key)
+}
+
+// KeyFromContext is the same as guard.KeyFromContext.
+func KeyFromContext(ctx context.Context) (string, bool) {
+	return guard.KeyFromContext(ctx)
+}
+
+// New creates a new guard.Guard that runs only one process at a time for
+// each key, and shares its result with all callers of the same key.
+//
+// The value is shared with the callers using guard.RunValue.
+// The shared process runs with a context that is detached from the callers,
+// and is cancelled only when all callers have gone.
+// Each caller returns the error of its own context as soon as it is done.
+// The process without a key runs as it is.
+func New(options ...Option) guard.Guard {
+	g := &group{
+		keyFunc: KeyFromContext,
+		calls:   make(map[string]*call),
+	}
+	for _, o := range options {
+		o(g)
+	}
+	return g
+}
+
+// Option is the optional parameter for New.
+type Option func(*group)
+
+// WithKeyFunc set the function that derives the key from the context.
+// The default is KeyFromContext.
+func WithKeyFunc(f func(ctx context.Context) (string, bool)) Option {
+	return Option(func(g *group) {
+		g.keyFunc = f
+	})
+}
+
+type call struct {
+	done   chan struct{}
+	err    error
+	value  interface{}
+	panic  interface{}
+	refs   int
+	cancel context.CancelFunc
+}
+
+type group struct {
+	keyFunc func(context.Context) (string, bool)
+	calls   map[string]*call
+	mu      sync.Mutex
+}
+
+func (g *group) Run(ctx context.Context, f func(context.Context) error) error {
+	key, ok := g.keyFunc(ctx)
+	if !ok {
+		return f(ctx)
+	}
+
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		c = g.start(ctx, key, f)
+	}
+	c.refs++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if c.panic != nil {
+			panic(c.panic)
+		}
+		if r := guard.ResultFromContext(ctx); r != nil {
+			r.SetValue(c.value)
+		}
+		return c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.refs--
+		if c.refs == 0 {
+			c.cancel()
+			g.forget(key, c)
+		}
+		g.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// start runs f in a new goroutine.
+// g.mu must be held.
+func (g *group) start(ctx context.Context, key string, f func(context.Context) error) *call {
+	result := &guard.Result{}
+	ctx, cancel := context.WithCancel(guard.Detach(ctx))
+	ctx = guard.WithResult(ctx, result)
+
+	c := &call{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	g.calls[key] = c
+
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				// propagate to the callers, since the panic in this goroutine
+				// cannot be recovered by them.
+				c.panic = e
+			}
+			g.mu.Lock()
+			g.forget(key, c)
+			g.mu.Unlock()
+			cancel()
+			close(c.done)
+		}()
+		c.err = f(ctx)
+		c.value = result.Value()
+	}()
+	return c
+}
+
+// forget removes the call so that the next process starts a new call.
+// g.mu must be held.
+func (g *group) forget(key string, c *call) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,144 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/morikuni/guard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleflight(t *testing.T) {
+	t.Run("error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New()
+
+		err := g.Run(WithKey(context.Background(), "key"), func(_ context.Context) error {
+			return errors.New("test error")
+		})
+
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("concurrent processes should be coalesced", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New()
+		ctx := WithKey(context.Background(), "key")
+
+		var count int32
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		values := make([]interface{}, 10)
+		errs := make([]error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				values[i], errs[i] = guard.RunValue(ctx, g, func(_ context.Context) (interface{}, error) {
+					atomic.AddInt32(&count, 1)
+					<-release
+					return "value", errors.New("test error")
+				})
+			}(i)
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(int32(1), count)
+		for i := 0; i < 10; i++ {
+			assert.Equal("value", values[i])
+			assert.EqualError(errs[i], "test error")
+		}
+	})
+
+	t.Run("processes with different keys should not be coalesced", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New(WithKeyFunc(func(ctx context.Context) (string, bool) {
+			key, ok := ctx.Value("id").(string)
+			return key, ok
+		}))
+
+		var count int32
+		f := func(_ context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		}
+		g.Run(context.WithValue(context.Background(), "id", "a"), f)
+		g.Run(context.WithValue(context.Background(), "id", "b"), f)
+		g.Run(context.Background(), f)
+
+		assert.Equal(int32(3), count)
+	})
+
+	t.Run("caller should return when its own context is done", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New()
+		ctx1, cancel1 := context.WithCancel(WithKey(context.Background(), "key"))
+		ctx2 := WithKey(context.Background(), "key")
+
+		release := make(chan struct{})
+		done1 := make(chan error)
+		done2 := make(chan error)
+		f := func(ctx context.Context) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		go func() { done1 <- g.Run(ctx1, f) }()
+		time.Sleep(10 * time.Millisecond)
+		go func() { done2 <- g.Run(ctx2, f) }()
+		time.Sleep(10 * time.Millisecond)
+
+		cancel1()
+		assert.Equal(context.Canceled, <-done1)
+
+		close(release)
+		assert.NoError(<-done2)
+	})
+
+	t.Run("shared process should be cancelled when all callers have gone", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New()
+		ctx, cancel := context.WithCancel(WithKey(context.Background(), "key"))
+
+		cancelled := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- g.Run(ctx, func(ctx context.Context) error {
+				<-ctx.Done()
+				close(cancelled)
+				return ctx.Err()
+			})
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		assert.Equal(context.Canceled, <-done)
+		<-cancelled
+	})
+
+	t.Run("panic should be propagated to the callers", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := New()
+
+		assert.PanicsWithValue("test error", func() {
+			g.Run(WithKey(context.Background(), "key"), func(_ context.Context) error {
+				panic("test error")
+			})
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package guard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Result holds the value returned by the function passed to RunValue.
+// Guards that handle values, such as a cache, read and write the value
+// through the Result held by the context.
+type Result struct {
+	value interface{}
+	mu    sync.RWMutex
+}
+
+// Value returns the value.
+func (r *Result) Value() interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.value
+}
+
+// SetValue sets the value.
+func (r *Result) SetValue(v interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.value = v
+}
+
+type resultKey struct{}
+
+// WithResult returns a new context that holds r.
+func WithResult(ctx context.Context, r *Result) context.Context {
+	return context.WithValue(ctx, resultKey{}, r)
+}
+
+// ResultFromContext returns the Result held by the context.
+// It returns nil if the context does not have the Result.
+func ResultFromContext(ctx context.Context) *Result {
+	r, _ := ctx.Value(resultKey{}).(*Result)
+	return r
+}
+
+type keyKey struct{}
+
+// WithKey returns a new context that holds the key of the process.
+// Guards that identify processes by a key, such as a cache and singleflight,
+// read the key by KeyFromContext by default, so that one key serves them all.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// KeyFromContext returns the key held by the context.
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(keyKey{}).(string)
+	return key, ok
+}
+
+// RunValue runs the function that returns a value with the guard,
+// and returns the value.
+func RunValue(ctx context.Context, g Guard, f func(context.Context) (interface{}, error)) (interface{}, error) {
+	r := &Result{}
+	err := g.Run(WithResult(ctx, r), func(ctx context.Context) error {
+		v, err := f(ctx)
+		if r := ResultFromContext(ctx); r != nil {
+			r.SetValue(v)
+		}
+		return err
+	})
+	return r.Value(), err
+}
+
+// Detach returns a new context that holds the values of ctx
+// but is never cancelled and has no deadline.
+// It is useful to continue the process beyond the lifetime of the caller.
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
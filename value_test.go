@@ -0,0 +1,56 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunValue(t *testing.T) {
+	t.Run("value and error should be returned", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := Compose(testGuard{}, testGuard{})
+
+		v, err := RunValue(context.Background(), g, func(_ context.Context) (interface{}, error) {
+			return 1, errors.New("test error")
+		})
+
+		assert.Equal(1, v)
+		assert.EqualError(err, "test error")
+	})
+
+	t.Run("guard should be able to replace the value", func(t *testing.T) {
+		assert := assert.New(t)
+
+		g := GuardFunc(func(ctx context.Context, f func(context.Context) error) error {
+			ResultFromContext(ctx).SetValue("cached")
+			return nil
+		})
+
+		v, err := RunValue(context.Background(), g, func(_ context.Context) (interface{}, error) {
+			return "fresh", nil
+		})
+
+		assert.NoError(err)
+		assert.Equal("cached", v)
+	})
+}
+
+func TestDetach(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), "aaa", "bbb"), time.Millisecond)
+	cancel()
+
+	d := Detach(ctx)
+
+	assert.NoError(d.Err())
+	assert.Nil(d.Done())
+	_, ok := d.Deadline()
+	assert.False(ok)
+	assert.Equal("bbb", d.Value("aaa"))
+}